@@ -67,7 +67,7 @@ func (c *ClockWithBuffering) EndTasksBuffering(ctx context.Context, liveTimeStar
 }
 
 func (c *ClockWithBuffering) processTaskInLive(t *Task) {
-	c.AfterFunc(time.Until(t.Deadline), func(now time.Time) {
+	c.AfterFunc(c.Clock.Until(t.Deadline), func(now time.Time) {
 		resTask := t.Run(now)
 
 		if resTask != nil {
@@ -106,6 +106,17 @@ func (c *ClockWithBuffering) AfterFunc(d time.Duration, f func(now time.Time)) T
 	return c.Clock.AfterFunc(d, f)
 }
 
+func (c *ClockWithBuffering) UntilFunc(t time.Time, f func(now time.Time)) Timer {
+	c.bufferingLock.Lock()
+	defer c.bufferingLock.Unlock()
+
+	if c.bufferingEnabled {
+		return c.tasksBuffer.UntilFunc(t, f)
+	}
+
+	return c.Clock.UntilFunc(t, f)
+}
+
 func (c *ClockWithBuffering) EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker {
 	c.bufferingLock.Lock()
 	defer c.bufferingLock.Unlock()
@@ -116,3 +127,14 @@ func (c *ClockWithBuffering) EveryFunc(d time.Duration, f func(now time.Time) bo
 
 	return c.Clock.EveryFunc(d, f)
 }
+
+func (c *ClockWithBuffering) RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	c.bufferingLock.Lock()
+	defer c.bufferingLock.Unlock()
+
+	if c.bufferingEnabled {
+		return c.tasksBuffer.RepeatFunc(d, f)
+	}
+
+	return RepeatFunc(c.Clock, d, f)
+}
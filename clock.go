@@ -1,6 +1,8 @@
 package chrono
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"time"
 )
@@ -14,30 +16,44 @@ type Clock interface {
 	EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker
 }
 
-var DefaultClock = NewRealClock()
-
 func Now() time.Time {
-	return DefaultClock.Now()
+	return DefaultClock().Now()
 }
 
 func Since(t time.Time) time.Duration {
-	return DefaultClock.Since(t)
+	return DefaultClock().Since(t)
 }
 
 func Until(t time.Time) time.Duration {
-	return DefaultClock.Until(t)
+	return DefaultClock().Until(t)
 }
 
 func AfterFunc(d time.Duration, f func(now time.Time)) Timer {
-	return DefaultClock.AfterFunc(d, f)
+	return DefaultClock().AfterFunc(d, f)
 }
 
 func UntilFunc(t time.Time, f func(now time.Time)) Timer {
-	return DefaultClock.UntilFunc(t, f)
+	return DefaultClock().UntilFunc(t, f)
 }
 
 func EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker {
-	return DefaultClock.EveryFunc(d, f)
+	return DefaultClock().EveryFunc(d, f)
+}
+
+// Clock, which implements RepeatFunc natively.
+type repeatingClock interface {
+	RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker
+}
+
+// RepeatFunc is like EveryFunc, but the handler chooses the delay until the next tick.
+// The delay is counted from the moment the tick was scheduled for.
+// Clocks of this package implement it natively. For other clocks, ticks are scheduled one by one with UntilFunc.
+func RepeatFunc(c Clock, d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	if r, ok := c.(repeatingClock); ok {
+		return r.RepeatFunc(d, f)
+	}
+
+	return newTimerRepeatTicker(c, d, f)
 }
 
 // NewRealClock implements Clock interface for real clock.
@@ -49,8 +65,20 @@ func NewRealClock() *RealClock {
 
 type RealClock struct {
 	handlersLock sync.Mutex
+
+	registryLock sync.Mutex
+	registry     map[registeredTask]struct{}
+	closed       bool
+}
+
+// Timer, ticker or wall clock watcher of RealClock, which must be stopped when the clock is closed.
+type registeredTask interface {
+	stopOnClose()
+	onWallClockJump()
 }
 
+var ErrClockClosed = errors.New("clock is closed")
+
 var _ Clock = &RealClock{}
 
 func (c *RealClock) Now() time.Time {
@@ -65,48 +93,133 @@ func (c *RealClock) Until(t time.Time) time.Duration {
 	return time.Until(t)
 }
 
+// If the clock is closed, returned timer never fires.
 func (c *RealClock) AfterFunc(d time.Duration, f func(now time.Time)) Timer {
-	if d == 0 {
-		go c.executeTask(f)
+	timer, _ := c.TryAfterFunc(d, f)
+	return timer
+}
 
-		return newExpiredTimer(c, f)
+// Same as AfterFunc, but returns ErrClockClosed if the clock is closed.
+func (c *RealClock) TryAfterFunc(d time.Duration, f func(now time.Time)) (Timer, error) {
+	timer, scheduled := newRealTimer(c, time.Now().Add(d), false, f)
+	if !scheduled {
+		return timer, ErrClockClosed
 	}
 
-	return time.AfterFunc(d, func() {
-		c.executeTask(f)
-	})
+	return timer, nil
 }
 
-func (c *RealClock) executeTask(t func(now time.Time)) {
+// Executes task, if condition is still true after handlers lock is acquired.
+func (c *RealClock) executeTaskIf(t func(now time.Time), cond func() bool) {
 	c.handlersLock.Lock()
 	defer c.handlersLock.Unlock()
 
+	if !cond() {
+		return
+	}
+
 	t(time.Now())
 }
 
+// If the clock is closed, returned timer never fires.
 func (c *RealClock) UntilFunc(t time.Time, f func(now time.Time)) Timer {
-	return c.AfterFunc(time.Until(t), f)
+	timer, _ := c.TryUntilFunc(t, f)
+	return timer
+}
+
+// Same as UntilFunc, but returns ErrClockClosed if the clock is closed.
+func (c *RealClock) TryUntilFunc(t time.Time, f func(now time.Time)) (Timer, error) {
+	timer, scheduled := newRealTimer(c, t, true, f)
+	if !scheduled {
+		return timer, ErrClockClosed
+	}
+
+	return timer, nil
 }
 
+// If the clock is closed, returned ticker never ticks.
 func (c *RealClock) EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker {
-	ticker := time.NewTicker(d)
+	ticker, _ := c.TryEveryFunc(d, f)
+	return ticker
+}
+
+// Same as EveryFunc, but returns ErrClockClosed if the clock is closed.
+func (c *RealClock) TryEveryFunc(d time.Duration, f func(now time.Time) bool) (Ticker, error) {
+	ticker, scheduled := newRealTicker(c, d, f)
+	if !scheduled {
+		return ticker, ErrClockClosed
+	}
+
+	return ticker, nil
+}
+
+// If the clock is closed, returned ticker never ticks.
+func (c *RealClock) RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	ticker, _ := c.TryRepeatFunc(d, f)
+	return ticker
+}
+
+// Same as RepeatFunc, but returns ErrClockClosed if the clock is closed.
+func (c *RealClock) TryRepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) (Ticker, error) {
+	ticker, scheduled := newRealRepeatTicker(c, d, f, false)
+	if !scheduled {
+		return ticker, ErrClockClosed
+	}
+
+	return ticker, nil
+}
+
+// Close stops all pending timers and tickers, and makes the clock reject new scheduling.
+// Then waits until handlers, which are running now, are finished, or until the context is done.
+// Must not be called from a handler of the same clock, because it would wait for itself.
+func (c *RealClock) Close(ctx context.Context) error {
+	c.registryLock.Lock()
+	c.closed = true
+	registered := c.registry
+	c.registry = nil
+	c.registryLock.Unlock()
+
+	for task := range registered {
+		task.stopOnClose()
+	}
+
+	handlersFinished := make(chan struct{})
 
 	go func() {
-		for range ticker.C {
-			contin := c.invokeTickHandler(f)
-			if !contin {
-				ticker.Stop()
-				return
-			}
-		}
+		c.handlersLock.Lock()
+		c.handlersLock.Unlock()
+		close(handlersFinished)
 	}()
 
-	return ticker
+	select {
+	case <-handlersFinished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (c *RealClock) invokeTickHandler(f func(now time.Time) bool) bool {
-	c.handlersLock.Lock()
-	defer c.handlersLock.Unlock()
+// Adds timer or ticker into the registry. Returns false if the clock is closed.
+func (c *RealClock) register(t registeredTask) bool {
+	c.registryLock.Lock()
+	defer c.registryLock.Unlock()
+
+	if c.closed {
+		return false
+	}
+
+	if c.registry == nil {
+		c.registry = make(map[registeredTask]struct{})
+	}
+
+	c.registry[t] = struct{}{}
+
+	return true
+}
+
+func (c *RealClock) unregister(t registeredTask) {
+	c.registryLock.Lock()
+	defer c.registryLock.Unlock()
 
-	return f(time.Now())
+	delete(c.registry, t)
 }
@@ -0,0 +1,66 @@
+package chrono_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRealClockClose(t *testing.T) {
+	t.Parallel()
+
+	c := chrono.NewRealClock()
+
+	var fired atomic.Int32
+	var handlerFinished atomic.Bool
+
+	handlerStarted := make(chan struct{})
+
+	c.AfterFunc(0, func(now time.Time) {
+		close(handlerStarted)
+		time.Sleep(100 * time.Millisecond)
+		handlerFinished.Store(true)
+	})
+
+	c.AfterFunc(50*time.Millisecond, func(now time.Time) {
+		fired.Add(1)
+	})
+
+	ticker := c.EveryFunc(20*time.Millisecond, func(now time.Time) bool {
+		fired.Add(1)
+		return true
+	})
+
+	<-handlerStarted
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, c.Close(ctx), context.DeadlineExceeded)
+
+	require.NoError(t, c.Close(context.Background()))
+	require.True(t, handlerFinished.Load())
+
+	_, err := c.TryAfterFunc(0, func(now time.Time) {
+		fired.Add(1)
+	})
+	require.ErrorIs(t, err, chrono.ErrClockClosed)
+
+	_, err = c.TryEveryFunc(time.Millisecond, func(now time.Time) bool {
+		fired.Add(1)
+		return true
+	})
+	require.ErrorIs(t, err, chrono.ErrClockClosed)
+
+	timer := c.AfterFunc(0, func(now time.Time) {
+		fired.Add(1)
+	})
+	require.False(t, timer.Reset(0))
+	ticker.Reset(time.Millisecond)
+
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, int32(0), fired.Load())
+}
@@ -0,0 +1,51 @@
+package chrono
+
+import (
+	"context"
+	"time"
+)
+
+type clockContextKey struct{}
+
+// WithClock returns context carrying the clock.
+// Use it to pass the simulator to deeply nested code without changing its signatures.
+func WithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey{}, c)
+}
+
+// FromContext returns the clock carried by the context, or DefaultClock if there is none.
+func FromContext(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return c
+	}
+
+	return DefaultClock()
+}
+
+func NowContext(ctx context.Context) time.Time {
+	return FromContext(ctx).Now()
+}
+
+func SinceContext(ctx context.Context, t time.Time) time.Duration {
+	return FromContext(ctx).Since(t)
+}
+
+func UntilContext(ctx context.Context, t time.Time) time.Duration {
+	return FromContext(ctx).Until(t)
+}
+
+func AfterFuncContext(ctx context.Context, d time.Duration, f func(now time.Time)) Timer {
+	return FromContext(ctx).AfterFunc(d, f)
+}
+
+func UntilFuncContext(ctx context.Context, t time.Time, f func(now time.Time)) Timer {
+	return FromContext(ctx).UntilFunc(t, f)
+}
+
+func EveryFuncContext(ctx context.Context, d time.Duration, f func(now time.Time) bool) Ticker {
+	return FromContext(ctx).EveryFunc(d, f)
+}
+
+func RepeatFuncContext(ctx context.Context, d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	return RepeatFunc(FromContext(ctx), d, f)
+}
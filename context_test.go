@@ -0,0 +1,33 @@
+package chrono_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockContext(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, chrono.DefaultClock(), chrono.FromContext(context.Background()))
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := chrono.NewSimulator(start)
+	ctx := chrono.WithClock(context.Background(), s)
+
+	require.Equal(t, s, chrono.FromContext(ctx))
+	require.Equal(t, start, chrono.NowContext(ctx))
+
+	var firedAt time.Time
+	chrono.AfterFuncContext(ctx, time.Hour, func(now time.Time) {
+		firedAt = chrono.NowContext(ctx)
+	})
+
+	s.ProcessAll(ctx)
+
+	require.Equal(t, start.Add(time.Hour), firedAt)
+	require.Equal(t, time.Hour, chrono.SinceContext(ctx, start))
+}
@@ -0,0 +1,78 @@
+package chrono
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type defaultClockState struct {
+	clock Clock
+	// Name of the test, which has overridden the default clock.
+	overriddenBy string
+}
+
+var defaultClock atomic.Pointer[defaultClockState]
+
+func init() {
+	defaultClock.Store(&defaultClockState{clock: NewRealClock()})
+}
+
+// DefaultClock returns the clock used by package-level functions like Now and AfterFunc.
+// Initially it is RealClock.
+func DefaultClock() Clock {
+	return defaultClock.Load().clock
+}
+
+// SetDefaultClock atomically replaces the clock used by package-level functions. Returns the previous clock.
+func SetDefaultClock(c Clock) (previous Clock) {
+	return defaultClock.Swap(&defaultClockState{clock: c}).clock
+}
+
+// TestingT is the subset of testing.TB used by OverrideDefaultClock.
+type TestingT interface {
+	Name() string
+	Helper()
+	Cleanup(f func())
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// OverrideDefaultClock replaces the default clock for the duration of the test,
+// and restores the previous one when the test and its subtests are finished.
+// Fails the test if the default clock is already overridden by another test,
+// or if it was changed by someone else during the test.
+//
+// NOTE: Overriding affects all the code using the default clock, including parallel tests.
+func OverrideDefaultClock(t TestingT, c Clock) {
+	t.Helper()
+
+	previous := defaultClock.Load()
+	if previous.overriddenBy != "" {
+		t.Fatalf("default clock is already overridden by test %v", previous.overriddenBy)
+		return
+	}
+
+	overridden := &defaultClockState{clock: c, overriddenBy: t.Name()}
+
+	if !defaultClock.CompareAndSwap(previous, overridden) {
+		t.Fatalf("default clock was concurrently changed while being overridden by test %v", t.Name())
+		return
+	}
+
+	t.Cleanup(func() {
+		if !defaultClock.CompareAndSwap(overridden, previous) {
+			t.Errorf("default clock was changed during test %v", t.Name())
+		}
+	})
+}
+
+// SimulateDefaultClock creates simulator and installs it as the default clock for the duration of the test.
+// See OverrideDefaultClock for details.
+func SimulateDefaultClock(t TestingT, now time.Time) *Simulator {
+	t.Helper()
+
+	s := NewSimulator(now)
+	OverrideDefaultClock(t, s)
+
+	return s
+}
@@ -0,0 +1,74 @@
+package chrono_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeT struct {
+	*testing.T
+	cleanups []func()
+	failures []string
+}
+
+func (t *fakeT) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, format)
+}
+
+func (t *fakeT) Fatalf(format string, args ...interface{}) {
+	t.failures = append(t.failures, format)
+}
+
+func (t *fakeT) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+// Not parallel, because default clock is global.
+func TestSimulateDefaultClock(t *testing.T) {
+	original := chrono.DefaultClock()
+
+	t.Run("simulated", func(t *testing.T) {
+		start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		s := chrono.SimulateDefaultClock(t, start)
+
+		var firedAt time.Time
+		chrono.AfterFunc(time.Hour, func(now time.Time) {
+			firedAt = chrono.Now()
+		})
+
+		s.ProcessAll(context.Background())
+		require.Equal(t, start.Add(time.Hour), firedAt)
+	})
+
+	require.Equal(t, original, chrono.DefaultClock())
+
+	first := &fakeT{T: t}
+	chrono.SimulateDefaultClock(first, time.Now())
+	require.Empty(t, first.failures)
+
+	second := &fakeT{T: t}
+	chrono.SimulateDefaultClock(second, time.Now())
+	require.Len(t, second.failures, 1)
+
+	first.finish()
+	require.Empty(t, first.failures)
+	require.Equal(t, original, chrono.DefaultClock())
+
+	third := &fakeT{T: t}
+	chrono.SimulateDefaultClock(third, time.Now())
+	chrono.SetDefaultClock(chrono.NewRealClock())
+	third.finish()
+	require.Len(t, third.failures, 1)
+
+	chrono.SetDefaultClock(original)
+}
@@ -0,0 +1,215 @@
+package chrono
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NewEventLoopClock creates real time clock, which executes all of its tasks in a single goroutine - the one calling Run.
+// Unlike RealClock, handlers can rely on goroutine affinity, and simultaneous tasks are executed
+// in the order of their deadlines, and then in the order they were scheduled.
+// Like RealClock, tickers drop ticks missed because of slow handlers.
+// Note that the simulator does not guarantee the order of tasks with equal deadlines.
+// Tasks can be scheduled before Run is called, they will be executed once the loop is started.
+func NewEventLoopClock() *EventLoopClock {
+	return &EventLoopClock{
+		taskQueue: newFIFOTaskQueue(),
+		wakeUp:    make(chan struct{}, 1),
+		closed:    make(chan struct{}),
+	}
+}
+
+type EventLoopClock struct {
+	lock      sync.Mutex
+	taskQueue *taskQueue
+	running   bool
+
+	wakeUp    chan struct{}
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+var _ Clock = &EventLoopClock{}
+var _ taskScheduler = &EventLoopClock{}
+
+// Run executes tasks until the context is done or the clock is closed.
+// Returns nil if the clock was closed, and context error otherwise.
+// Must not be called concurrently.
+func (c *EventLoopClock) Run(ctx context.Context) error {
+	c.lock.Lock()
+	if c.running {
+		c.lock.Unlock()
+		panic("event loop is already running")
+	}
+	c.running = true
+	c.lock.Unlock()
+
+	defer func() {
+		c.lock.Lock()
+		c.running = false
+		c.lock.Unlock()
+	}()
+
+	waitTimer := time.NewTimer(time.Hour)
+	defer waitTimer.Stop()
+
+	for {
+		select {
+		case <-c.closed:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		wait, hasTasks := c.runNextTask()
+		if hasTasks && wait <= 0 {
+			continue
+		}
+
+		var waitC <-chan time.Time
+		if hasTasks {
+			waitTimer.Reset(wait)
+			waitC = waitTimer.C
+		}
+
+		select {
+		case <-waitC:
+		case <-c.wakeUp:
+		case <-c.closed:
+		case <-ctx.Done():
+		}
+
+		if hasTasks && !waitTimer.Stop() {
+			select {
+			case <-waitTimer.C:
+			default:
+			}
+		}
+	}
+}
+
+// Close stops the event loop. Pending tasks are not executed.
+// If a task is running, Run returns after it is finished.
+func (c *EventLoopClock) Close() {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+}
+
+// Runs next task if it is expired. Otherwise returns the time left until its deadline.
+func (c *EventLoopClock) runNextTask() (wait time.Duration, hasTasks bool) {
+	c.lock.Lock()
+
+	if !c.taskQueue.HasTasks() {
+		c.lock.Unlock()
+		return 0, false
+	}
+
+	now := time.Now()
+
+	if !c.taskQueue.HasExpiredTasks(now) {
+		wait := c.taskQueue.PeekTask().Deadline.Sub(now)
+		c.lock.Unlock()
+		return wait, true
+	}
+
+	task := c.taskQueue.PopTaskForRun()
+	c.lock.Unlock()
+
+	followingTask := task.Run(now)
+
+	c.lock.Lock()
+	c.taskQueue.FinishTaskRun(task, followingTask)
+	c.lock.Unlock()
+
+	return 0, true
+}
+
+func (c *EventLoopClock) pushTask(task *Task) {
+	c.lock.Lock()
+	c.taskQueue.PushTask(task)
+	c.lock.Unlock()
+
+	c.notifyWakeUp()
+}
+
+func (c *EventLoopClock) notifyWakeUp() {
+	select {
+	case c.wakeUp <- struct{}{}:
+	default:
+	}
+}
+
+func (c *EventLoopClock) removeTask(task *Task, _ string) (wasPending bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.taskQueue.CancelTask(task)
+}
+
+func (c *EventLoopClock) resetTask(task *Task, d time.Duration, method string) (wasPending bool) {
+	return c.resetTaskAt(task, time.Now().Add(d), method)
+}
+
+func (c *EventLoopClock) resetTaskAt(task *Task, deadline time.Time, _ string) (wasPending bool) {
+	c.lock.Lock()
+	wasPending = c.taskQueue.RescheduleTask(task, deadline)
+	c.lock.Unlock()
+
+	c.notifyWakeUp()
+
+	return wasPending
+}
+
+func (c *EventLoopClock) postponeRunningTask(task *Task, d time.Duration, dropMissed bool) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.taskQueue.PostponeRunningTask(task, d, dropMissed, time.Now())
+}
+
+func (c *EventLoopClock) taskState(task *Task) taskState {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.taskQueue.TaskState(task)
+}
+
+func (c *EventLoopClock) Now() time.Time {
+	return time.Now()
+}
+
+func (c *EventLoopClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (c *EventLoopClock) Until(t time.Time) time.Duration {
+	return time.Until(t)
+}
+
+func (c *EventLoopClock) AfterFunc(d time.Duration, f func(now time.Time)) Timer {
+	return c.UntilFunc(time.Now().Add(d), f)
+}
+
+func (c *EventLoopClock) UntilFunc(t time.Time, f func(now time.Time)) Timer {
+	timer, task := newSimTimer(c, t, f)
+	c.pushTask(task)
+
+	return timer
+}
+
+func (c *EventLoopClock) EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker {
+	ticker, task := newSimTicker(c, time.Now().Add(d), d, f)
+	c.pushTask(task)
+
+	return ticker
+}
+
+func (c *EventLoopClock) RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	ticker, task := newSimRepeatTicker(c, "repeater", f, time.Now().Add(d), d, false, f)
+	c.pushTask(task)
+
+	return ticker
+}
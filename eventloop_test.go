@@ -0,0 +1,127 @@
+package chrono_test
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLoopClock(t *testing.T) {
+	t.Parallel()
+
+	c := chrono.NewEventLoopClock()
+
+	// Handlers are executed in the loop goroutine, so no synchronization is needed
+	var res []int
+	var ticks int
+	var handlerGoroutines []uint64
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+
+	for i := 1; i <= 5; i++ {
+		i := i
+		c.UntilFunc(deadline, func(now time.Time) {
+			handlerGoroutines = append(handlerGoroutines, goroutineID())
+			require.False(t, now.Before(deadline))
+			res = append(res, i)
+		})
+	}
+
+	stopped := c.AfterFunc(10*time.Millisecond, func(now time.Time) {
+		res = append(res, -1)
+	})
+	require.True(t, stopped.Stop())
+
+	c.EveryFunc(20*time.Millisecond, func(now time.Time) bool {
+		handlerGoroutines = append(handlerGoroutines, goroutineID())
+		ticks++
+		return ticks < 3
+	})
+
+	c.AfterFunc(150*time.Millisecond, func(now time.Time) {
+		handlerGoroutines = append(handlerGoroutines, goroutineID())
+		c.Close()
+	})
+
+	// Run is called from other goroutine, which is not the one of the test
+	runGoroutine := make(chan uint64, 1)
+	runResult := make(chan error, 1)
+	start := time.Now()
+
+	go func() {
+		runGoroutine <- goroutineID()
+		runResult <- c.Run(context.Background())
+	}()
+
+	require.NoError(t, <-runResult)
+	require.True(t, time.Since(start) >= 150*time.Millisecond)
+	require.Equal(t, []int{1, 2, 3, 4, 5}, res)
+	require.Equal(t, 3, ticks)
+
+	loopGoroutine := <-runGoroutine
+	require.NotEqual(t, goroutineID(), loopGoroutine)
+	require.Len(t, handlerGoroutines, 9)
+	for _, g := range handlerGoroutines {
+		require.Equal(t, loopGoroutine, g)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	c = chrono.NewEventLoopClock()
+	require.ErrorIs(t, c.Run(ctx), context.DeadlineExceeded)
+}
+
+func TestEventLoopClockDropsMissedTicks(t *testing.T) {
+	t.Parallel()
+
+	c := chrono.NewEventLoopClock()
+
+	const period = 10 * time.Millisecond
+
+	var ticks []time.Time
+	var slowTickEnd time.Time
+
+	c.EveryFunc(period, func(now time.Time) bool {
+		ticks = append(ticks, now)
+
+		switch len(ticks) {
+		case 1:
+			time.Sleep(5 * period)
+			slowTickEnd = time.Now()
+		case 3:
+			c.Close()
+			return false
+		}
+
+		return true
+	})
+
+	require.NoError(t, c.Run(context.Background()))
+	require.Len(t, ticks, 3)
+	// Ticks missed during the slow handler are dropped instead of being executed all at once, same as with RealClock
+	require.False(t, ticks[2].Before(slowTickEnd.Add(period)))
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+func goroutineID() uint64 {
+	var buf [64]byte
+	stack := buf[:runtime.Stack(buf[:], false)]
+
+	stack = bytes.TrimPrefix(stack, goroutinePrefix)
+	stack = stack[:bytes.IndexByte(stack, ' ')]
+
+	id, err := strconv.ParseUint(string(stack), 10, 64)
+	if err != nil {
+		panic(err)
+	}
+
+	return id
+}
@@ -0,0 +1,137 @@
+package chrono
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Inbox is a thread-safe queue of events posted from other goroutines.
+// Events are not executed when posted - simulator applies them between tasks,
+// passing the current simulated time to their handlers. So no data races are possible
+// between event handlers and the tasks of the simulator.
+//
+// Events, collected between two drains, are applied ordered by their source name,
+// and then by the order in which they were posted. So if each source posts its events from a single goroutine,
+// the order does not depend on goroutines scheduling.
+//
+// Inbox has limited capacity. When it is full, Post blocks until simulator drains it.
+//
+// NOTE: Inbox does not prevent simulator from finishing or advancing time while events are
+// still being prepared by producers. Use Simulator.Hold for that.
+type Inbox struct {
+	sim    *Simulator
+	slots  chan struct{}
+	lock   sync.Mutex
+	events []inboxEvent
+}
+
+type inboxEvent struct {
+	source string
+	action func(now time.Time)
+}
+
+// NewInbox creates new inbox, events of which will be applied by the simulator.
+// Inboxes are drained in the order of their creation.
+func (s *Simulator) NewInbox(capacity int) *Inbox {
+	if capacity <= 0 {
+		panic("inbox capacity must be positive")
+	}
+
+	inbox := &Inbox{
+		sim:   s,
+		slots: make(chan struct{}, capacity),
+	}
+
+	s.inboxesLock.Lock()
+	s.inboxes = append(s.inboxes, inbox)
+	s.inboxesLock.Unlock()
+
+	return inbox
+}
+
+// Post adds event into the inbox. If inbox is full, waits until there is free space or the context is done.
+func (i *Inbox) Post(ctx context.Context, source string, f func(now time.Time)) error {
+	select {
+	case i.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	i.push(source, f)
+
+	return nil
+}
+
+// TryPost adds event into the inbox if there is free space. Returns false if inbox is full.
+func (i *Inbox) TryPost(source string, f func(now time.Time)) bool {
+	select {
+	case i.slots <- struct{}{}:
+	default:
+		return false
+	}
+
+	i.push(source, f)
+
+	return true
+}
+
+// Len returns the number of events waiting to be applied.
+func (i *Inbox) Len() int {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	return len(i.events)
+}
+
+func (i *Inbox) push(source string, f func(now time.Time)) {
+	i.lock.Lock()
+	i.events = append(i.events, inboxEvent{source: source, action: f})
+	i.lock.Unlock()
+
+	i.sim.notifyWakeUp()
+}
+
+func (i *Inbox) drain() []inboxEvent {
+	i.lock.Lock()
+	events := i.events
+	i.events = nil
+	i.lock.Unlock()
+
+	for range events {
+		<-i.slots
+	}
+
+	sort.SliceStable(events, func(a, b int) bool {
+		return events[a].source < events[b].source
+	})
+
+	return events
+}
+
+// Applies all the events from inboxes at the current time. Returns the number of events applied.
+func (s *Simulator) drainInboxes() int {
+	s.inboxesLock.Lock()
+	inboxes := s.inboxes
+	s.inboxesLock.Unlock()
+
+	applied := 0
+
+	for _, inbox := range inboxes {
+		events := inbox.drain()
+		if len(events) == 0 {
+			continue
+		}
+
+		now := s.Now()
+
+		for _, e := range events {
+			e.action(now)
+		}
+
+		applied += len(events)
+	}
+
+	return applied
+}
@@ -0,0 +1,122 @@
+package chrono_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInbox(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	inbox := s.NewInbox(2)
+
+	var res []string
+
+	s.AfterFunc(time.Minute, func(now time.Time) {
+		release := s.Hold()
+
+		var wg sync.WaitGroup
+
+		for _, source := range []string{"b", "a"} {
+			source := source
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for i := 0; i < 3; i++ {
+					msg := fmt.Sprintf("%v%v", source, i)
+
+					err := inbox.Post(context.Background(), source, func(now time.Time) {
+						require.Equal(t, start.Add(time.Minute), now)
+						res = append(res, msg)
+					})
+					require.NoError(t, err)
+				}
+			}()
+		}
+
+		go func() {
+			wg.Wait()
+			release()
+		}()
+	})
+
+	_, err := s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.Len(t, res, 6)
+	require.Equal(t, 0, inbox.Len())
+
+	for _, source := range []string{"a", "b"} {
+		var fromSource []string
+		for _, msg := range res {
+			if msg[:1] == source {
+				fromSource = append(fromSource, msg)
+			}
+		}
+		require.Equal(t, []string{source + "0", source + "1", source + "2"}, fromSource)
+	}
+}
+
+func TestInboxOrderAndCapacity(t *testing.T) {
+	t.Parallel()
+
+	s := chrono.NewSimulator(time.Now())
+	inbox := s.NewInbox(3)
+
+	var res []string
+
+	for _, msg := range []string{"c1", "a1", "c2"} {
+		msg := msg
+		require.True(t, inbox.TryPost(msg[:1], func(now time.Time) {
+			res = append(res, msg)
+		}))
+	}
+
+	require.False(t, inbox.TryPost("b", func(now time.Time) {}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, inbox.Post(ctx, "b", func(now time.Time) {}), context.DeadlineExceeded)
+
+	tasksProcessed, err := s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, tasksProcessed)
+	require.Equal(t, []string{"a1", "c1", "c2"}, res)
+	require.True(t, inbox.TryPost("b", func(now time.Time) {}))
+}
+
+func TestInboxEventsNotLimitedAsTasks(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	s.SetLimits(chrono.SimulatorLimits{MaxTotalTasks: 3})
+	inbox := s.NewInbox(10)
+
+	var applied int
+
+	for i := 0; i < 10; i++ {
+		err := inbox.Post(context.Background(), "a", func(now time.Time) {
+			applied++
+		})
+		require.NoError(t, err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		s.AfterFunc(time.Duration(i)*time.Second, func(now time.Time) {})
+	}
+
+	tasksProcessed, err := s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 10, applied)
+	require.Equal(t, 13, tasksProcessed)
+}
@@ -0,0 +1,107 @@
+package chrono
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// SimulatorLimits protects ProcessAll and ProcessAllUntil from running forever,
+// e.g. because of a ticker which never stops or a handler which reschedules itself with zero delay.
+// Zero value of any field means no limit.
+type SimulatorLimits struct {
+	// Maximum number of tasks processed at the same simulated moment.
+	MaxTasksPerInstant int
+	// Maximum number of tasks processed by single call of ProcessAll or ProcessAllUntil.
+	// Events from inboxes are not counted, because they come from outside of the simulation.
+	MaxTotalTasks int
+	// Maximum duration of simulated time, which single call of ProcessAll or ProcessAllUntil can advance.
+	MaxHorizon time.Duration
+}
+
+var ErrLimitExceeded = errors.New("simulation limit exceeded")
+
+// LimitExceededError is returned by ProcessAll and ProcessAllUntil when one of the SimulatorLimits is exceeded.
+type LimitExceededError struct {
+	// Name of the exceeded limit field of SimulatorLimits.
+	Limit string
+	// Task, which would exceed the limit. It was not run.
+	Task     string
+	Deadline time.Time
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("%v: %v exceeded by task %q with deadline %v", ErrLimitExceeded, e.Limit, e.Task, e.Deadline)
+}
+
+func (e *LimitExceededError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// SetLimits sets the limits for the following calls of ProcessAll and ProcessAllUntil.
+func (s *Simulator) SetLimits(limits SimulatorLimits) {
+	s.limitsLock.Lock()
+	defer s.limitsLock.Unlock()
+
+	s.limits = limits
+}
+
+func (s *Simulator) Limits() SimulatorLimits {
+	s.limitsLock.Lock()
+	defer s.limitsLock.Unlock()
+
+	return s.limits
+}
+
+func (s *Simulator) newLimitsChecker() *limitsChecker {
+	return &limitsChecker{
+		limits: s.Limits(),
+		start:  s.Now(),
+	}
+}
+
+type limitsChecker struct {
+	limits SimulatorLimits
+	start  time.Time
+	// Number of tasks allowed to run. Unlike the result of ProcessAll, does not include events from inboxes.
+	tasksAllowed int
+
+	instant      time.Time
+	instantTasks int
+}
+
+func (c *limitsChecker) Check(nextTask *Task) error {
+	if c.limits.MaxTotalTasks > 0 && c.tasksAllowed >= c.limits.MaxTotalTasks {
+		return c.exceeded("MaxTotalTasks", nextTask)
+	}
+
+	if c.limits.MaxHorizon > 0 && nextTask.Deadline.Sub(c.start) > c.limits.MaxHorizon {
+		return c.exceeded("MaxHorizon", nextTask)
+	}
+
+	if c.limits.MaxTasksPerInstant > 0 {
+		// Tasks with deadline in the past are run at the current moment
+		if nextTask.Deadline.After(c.instant) {
+			c.instant = nextTask.Deadline
+			c.instantTasks = 0
+		}
+
+		if c.instantTasks >= c.limits.MaxTasksPerInstant {
+			return c.exceeded("MaxTasksPerInstant", nextTask)
+		}
+
+		c.instantTasks++
+	}
+
+	c.tasksAllowed++
+
+	return nil
+}
+
+func (c *limitsChecker) exceeded(limit string, task *Task) error {
+	return &LimitExceededError{
+		Limit:    limit,
+		Task:     task.Name(),
+		Deadline: task.Deadline,
+	}
+}
@@ -0,0 +1,693 @@
+package chrono
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Summary of the monitor, which is included into the report.
+// For level monitors values are weighted by time, for counter monitors values are counts per window.
+type MonitorSummary struct {
+	Name string
+	Kind string
+	// Number of changes, observations or counted events.
+	Count int
+	Mean  float64
+	Min   float64
+	Max   float64
+	P50   float64
+	P95   float64
+	P99   float64
+}
+
+type HistogramBucket struct {
+	// Bucket contains values in range (previous bucket bound, UpperBound]. Last bucket bound is +Inf.
+	UpperBound float64
+	// Part of the total weight (time or number of observations) in the bucket.
+	Fraction float64
+}
+
+type monitor interface {
+	Summary() MonitorSummary
+}
+
+// NewMonitors creates set of monitors, which produces common report.
+// Use ReportOnProcessingDone to receive the report at the end of each ProcessAll, or call Report when needed.
+// Time-weighted values are calculated up to the current time of the clock at the moment of the report.
+func NewMonitors(c Clock) *Monitors {
+	return &Monitors{clock: c}
+}
+
+type Monitors struct {
+	clock Clock
+
+	lock     sync.Mutex
+	monitors []monitor
+}
+
+func (m *Monitors) Level(name string, initial float64) *LevelMonitor {
+	return addMonitor(m, NewLevelMonitor(m.clock, name, initial))
+}
+
+func (m *Monitors) Tally(name string) *TallyMonitor {
+	return addMonitor(m, NewTallyMonitor(name))
+}
+
+func (m *Monitors) Counter(name string, window time.Duration) *CounterMonitor {
+	return addMonitor(m, NewCounterMonitor(m.clock, name, window))
+}
+
+func addMonitor[M monitor](m *Monitors, mon M) M {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.monitors = append(m.monitors, mon)
+
+	return mon
+}
+
+// Report returns summaries of all the monitors in the order they were created.
+func (m *Monitors) Report() MonitorsReport {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	report := make(MonitorsReport, 0, len(m.monitors))
+	for _, mon := range m.monitors {
+		report = append(report, mon.Summary())
+	}
+
+	return report
+}
+
+// ReportOnProcessingDone calls f with the report each time ProcessAll, ProcessAllUntil or AdvanceTo*
+// of the simulator returns. The simulator should be the clock of the monitors, or the one behind it (e.g. for Node).
+// Returned function stops reporting.
+func (m *Monitors) ReportOnProcessingDone(s *Simulator, f func(report MonitorsReport)) (stop func()) {
+	return s.OnProcessingDone(func(_ time.Time) {
+		f(m.Report())
+	})
+}
+
+type MonitorsReport []MonitorSummary
+
+// Formats report as a table.
+func (r MonitorsReport) String() string {
+	var sb strings.Builder
+
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tCOUNT\tMEAN\tMIN\tMAX\tP50\tP95\tP99")
+
+	for _, s := range r {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\t%.4g\n", s.Name, s.Kind, s.Count, s.Mean, s.Min, s.Max, s.P50, s.P95, s.P99)
+	}
+
+	w.Flush()
+
+	return sb.String()
+}
+
+const (
+	// Number of distinct values, up to which percentiles and histograms are exact.
+	maxExactValues = 1024
+	// Relative error of values after there are too many distinct values to keep them all.
+	approximateValuesAccuracy = 0.01
+)
+
+var approximateValuesGamma = (1 + approximateValuesAccuracy) / (1 - approximateValuesAccuracy)
+
+// Values with their weights, used to calculate percentiles and histograms.
+// Values are kept exactly while there are few distinct ones (e.g. queue lengths). After that,
+// like in DDSketch, each value is replaced with the representative of its logarithmic bucket, so that memory
+// is bounded by the range of values (~115 buckets per order of magnitude), and results have 1% relative error.
+// Percentiles and histograms sort the kept values, so their cost is O(k log k) of the number of kept values.
+type weightedValues struct {
+	weights     map[float64]float64
+	total       float64
+	approximate bool
+}
+
+func newWeightedValues() weightedValues {
+	return weightedValues{weights: make(map[float64]float64)}
+}
+
+func (v *weightedValues) clone() weightedValues {
+	c := *v
+	c.weights = maps.Clone(v.weights)
+
+	return c
+}
+
+func (v *weightedValues) add(value, weight float64) {
+	if weight <= 0 {
+		return
+	}
+
+	if v.approximate {
+		value = approximateValue(value)
+	}
+
+	v.weights[value] += weight
+	v.total += weight
+
+	if !v.approximate && len(v.weights) > maxExactValues {
+		v.compress()
+	}
+}
+
+// Switches to approximate values.
+func (v *weightedValues) compress() {
+	approximate := weightedValues{
+		weights:     make(map[float64]float64),
+		total:       v.total,
+		approximate: true,
+	}
+
+	for _, value := range v.sortedValues() {
+		approximate.weights[approximateValue(value)] += v.weights[value]
+	}
+
+	*v = approximate
+}
+
+// Returns the representative value of the logarithmic bucket, which contains the value.
+func approximateValue(value float64) float64 {
+	if value == 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+
+	i := math.Ceil(math.Log(math.Abs(value)) / math.Log(approximateValuesGamma))
+	representative := 2 * math.Pow(approximateValuesGamma, i) / (approximateValuesGamma + 1)
+
+	return math.Copysign(representative, value)
+}
+
+// Returns the smallest value, for which at least p percents of total weight are at or below it.
+// Returns NaN if there are no values.
+func (v *weightedValues) percentile(p float64) float64 {
+	if v.total == 0 {
+		return math.NaN()
+	}
+
+	values := v.sortedValues()
+	threshold := p / 100 * v.total
+
+	var cumulative float64
+	for _, value := range values {
+		cumulative += v.weights[value]
+		if cumulative >= threshold {
+			return value
+		}
+	}
+
+	return values[len(values)-1]
+}
+
+func (v *weightedValues) histogram(bounds []float64) []HistogramBucket {
+	bounds = append(append([]float64(nil), bounds...), math.Inf(1))
+	sort.Float64s(bounds)
+
+	buckets := make([]HistogramBucket, len(bounds))
+	for i, bound := range bounds {
+		buckets[i].UpperBound = bound
+	}
+
+	if v.total == 0 {
+		return buckets
+	}
+
+	// Summed in order of values, so that the result does not depend on the order of map iteration
+	for _, value := range v.sortedValues() {
+		i := sort.SearchFloat64s(bounds, value)
+		buckets[i].Fraction += v.weights[value]
+	}
+
+	for i := range buckets {
+		buckets[i].Fraction /= v.total
+	}
+
+	return buckets
+}
+
+func (v *weightedValues) sortedValues() []float64 {
+	values := make([]float64, 0, len(v.weights))
+	for value := range v.weights {
+		values = append(values, value)
+	}
+
+	sort.Float64s(values)
+
+	return values
+}
+
+// Time-weighted average of a value, which changes in steps.
+// Optionally tracks time, during which each of the values was held, to calculate percentiles and histograms.
+type timeAverage struct {
+	start    time.Time
+	last     time.Time
+	value    float64
+	integral float64
+	// Time in seconds, during which each of the values was held, up to the last change. Nil if not tracked.
+	values *weightedValues
+}
+
+func newTimeAverage(now time.Time, value float64) timeAverage {
+	return timeAverage{start: now, last: now, value: value}
+}
+
+func newTrackedTimeAverage(now time.Time, value float64) timeAverage {
+	a := newTimeAverage(now, value)
+	values := newWeightedValues()
+	a.values = &values
+
+	return a
+}
+
+func (a *timeAverage) set(now time.Time, value float64) {
+	held := now.Sub(a.last)
+
+	a.integral += a.value * float64(held)
+	if a.values != nil {
+		a.values.add(a.value, held.Seconds())
+	}
+
+	a.last = now
+	a.value = value
+}
+
+// Accounts the time, during which the current value is held, without changing it.
+func (a *timeAverage) flush(now time.Time) {
+	a.set(now, a.value)
+}
+
+func (a *timeAverage) mean(now time.Time) float64 {
+	elapsed := now.Sub(a.start)
+	if elapsed <= 0 {
+		return a.value
+	}
+
+	return (a.integral + a.value*float64(now.Sub(a.last))) / float64(elapsed)
+}
+
+// NewLevelMonitor creates monitor of a value, which changes in steps - e.g. queue length or number of busy workers.
+// Its statistics are weighted by time: value, which was held for longer, has bigger impact.
+// Percentiles and histograms are exact for up to 1024 distinct values, and have 1% relative error for more.
+func NewLevelMonitor(c Clock, name string, initial float64) *LevelMonitor {
+	return &LevelMonitor{
+		clock: c,
+		name:  name,
+		level: newTrackedTimeAverage(c.Now(), initial),
+		min:   initial,
+		max:   initial,
+	}
+}
+
+type LevelMonitor struct {
+	clock Clock
+	name  string
+
+	lock     sync.Mutex
+	level    timeAverage
+	changes  int
+	min, max float64
+}
+
+var _ monitor = &LevelMonitor{}
+
+func (m *LevelMonitor) Set(value float64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.set(value)
+}
+
+func (m *LevelMonitor) Add(delta float64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.set(m.level.value + delta)
+}
+
+// Must be called under lock.
+func (m *LevelMonitor) set(value float64) {
+	m.level.set(m.clock.Now(), value)
+	m.changes++
+	m.min = min(m.min, value)
+	m.max = max(m.max, value)
+}
+
+func (m *LevelMonitor) Value() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.level.value
+}
+
+// Returns time-weighted average since the creation of the monitor.
+// If no time passed yet, returns the current value.
+func (m *LevelMonitor) Mean() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.level.mean(m.clock.Now())
+}
+
+func (m *LevelMonitor) Min() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.min
+}
+
+func (m *LevelMonitor) Max() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.max
+}
+
+// Returns the value, at or below which the monitored value was held for p percents of time.
+func (m *LevelMonitor) Percentile(p float64) float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.percentile(p)
+}
+
+// Must be called under lock.
+func (m *LevelMonitor) percentile(p float64) float64 {
+	m.level.flush(m.clock.Now())
+
+	if m.level.values.total == 0 {
+		return m.level.value
+	}
+
+	return m.level.values.percentile(p)
+}
+
+// Returns parts of time, during which the value was in each of the buckets.
+func (m *LevelMonitor) Histogram(bounds []float64) []HistogramBucket {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.level.flush(m.clock.Now())
+
+	return m.level.values.histogram(bounds)
+}
+
+// Returns time passed since the creation of the monitor.
+func (m *LevelMonitor) Duration() time.Duration {
+	return m.clock.Since(m.level.start)
+}
+
+func (m *LevelMonitor) Summary() MonitorSummary {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return MonitorSummary{
+		Name:  m.name,
+		Kind:  "level",
+		Count: m.changes,
+		Mean:  m.level.mean(m.clock.Now()),
+		Min:   m.min,
+		Max:   m.max,
+		P50:   m.percentile(50),
+		P95:   m.percentile(95),
+		P99:   m.percentile(99),
+	}
+}
+
+// NewTallyMonitor creates monitor of independent observations - e.g. wait times of requests.
+// Percentiles and histograms are exact for up to 1024 distinct values, and have 1% relative error for more.
+// Mean, min, max and standard deviation are always exact.
+func NewTallyMonitor(name string) *TallyMonitor {
+	return &TallyMonitor{
+		name:    name,
+		min:     math.NaN(),
+		max:     math.NaN(),
+		weights: newWeightedValues(),
+	}
+}
+
+type TallyMonitor struct {
+	name string
+
+	lock     sync.Mutex
+	count    int
+	sum      float64
+	sumSq    float64
+	min, max float64
+	weights  weightedValues
+}
+
+var _ monitor = &TallyMonitor{}
+
+func (m *TallyMonitor) Observe(value float64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.count == 0 {
+		m.min, m.max = value, value
+	}
+
+	m.count++
+	m.sum += value
+	m.sumSq += value * value
+	m.min = min(m.min, value)
+	m.max = max(m.max, value)
+	m.weights.add(value, 1)
+}
+
+// Observes duration in seconds.
+func (m *TallyMonitor) ObserveDuration(d time.Duration) {
+	m.Observe(d.Seconds())
+}
+
+func (m *TallyMonitor) Count() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.count
+}
+
+// Returns NaN if there are no observations.
+func (m *TallyMonitor) Mean() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.mean()
+}
+
+// Must be called under lock.
+func (m *TallyMonitor) mean() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+
+	return m.sum / float64(m.count)
+}
+
+// Returns population standard deviation, or NaN if there are no observations.
+func (m *TallyMonitor) StdDev() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.count == 0 {
+		return math.NaN()
+	}
+
+	mean := m.mean()
+
+	return math.Sqrt(max(m.sumSq/float64(m.count)-mean*mean, 0))
+}
+
+// Returns NaN if there are no observations.
+func (m *TallyMonitor) Min() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.min
+}
+
+// Returns NaN if there are no observations.
+func (m *TallyMonitor) Max() float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.max
+}
+
+// Returns the value, at or below which p percents of observations are. Returns NaN if there are no observations.
+func (m *TallyMonitor) Percentile(p float64) float64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.weights.percentile(p)
+}
+
+// Returns parts of observations in each of the buckets.
+func (m *TallyMonitor) Histogram(bounds []float64) []HistogramBucket {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.weights.histogram(bounds)
+}
+
+func (m *TallyMonitor) Summary() MonitorSummary {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return MonitorSummary{
+		Name:  m.name,
+		Kind:  "tally",
+		Count: m.count,
+		Mean:  m.mean(),
+		Min:   m.min,
+		Max:   m.max,
+		P50:   m.weights.percentile(50),
+		P95:   m.weights.percentile(95),
+		P99:   m.weights.percentile(99),
+	}
+}
+
+// NewCounterMonitor creates monitor, which counts events in consecutive windows of time - e.g. requests per second.
+// Windows start at the moment of the monitor creation.
+func NewCounterMonitor(c Clock, name string, window time.Duration) *CounterMonitor {
+	if window <= 0 {
+		panic("non-positive counter window")
+	}
+
+	return &CounterMonitor{
+		clock:    c,
+		name:     name,
+		start:    c.Now(),
+		window:   window,
+		finished: newWeightedValues(),
+	}
+}
+
+type CounterMonitor struct {
+	clock  Clock
+	name   string
+	start  time.Time
+	window time.Duration
+
+	lock         sync.Mutex
+	current      int
+	currentCount int
+	// Counts of the finished windows. Only the current window is kept separately, so memory does not grow with time.
+	finished    weightedValues
+	finishedMin int
+	finishedMax int
+	total       int
+}
+
+var _ monitor = &CounterMonitor{}
+
+type CounterWindow struct {
+	Start time.Time
+	Count int
+}
+
+func (m *CounterMonitor) Inc() {
+	m.Add(1)
+}
+
+func (m *CounterMonitor) Add(n int) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.extend()
+
+	m.currentCount += n
+	m.total += n
+}
+
+// Finishes the windows before the current one. Must be called under lock.
+func (m *CounterMonitor) extend() {
+	elapsed := max(m.clock.Since(m.start), 0)
+	current := int(elapsed / m.window)
+
+	if current <= m.current {
+		return
+	}
+
+	m.finishWindows(m.currentCount, 1)
+
+	if empty := current - m.current - 1; empty > 0 {
+		m.finishWindows(0, empty)
+	}
+
+	m.current = current
+	m.currentCount = 0
+}
+
+// Must be called under lock.
+func (m *CounterMonitor) finishWindows(count, windows int) {
+	if m.finished.total == 0 {
+		m.finishedMin, m.finishedMax = count, count
+	}
+
+	m.finishedMin = min(m.finishedMin, count)
+	m.finishedMax = max(m.finishedMax, count)
+	m.finished.add(float64(count), float64(windows))
+}
+
+func (m *CounterMonitor) Total() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.total
+}
+
+// Returns the current window, which is not finished yet.
+func (m *CounterMonitor) CurrentWindow() CounterWindow {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.extend()
+
+	return CounterWindow{
+		Start: m.start.Add(time.Duration(m.current) * m.window),
+		Count: m.currentCount,
+	}
+}
+
+// Summary of counts per window. Current window is included only if it is not empty,
+// so that unfinished window does not lower the statistics.
+func (m *CounterMonitor) Summary() MonitorSummary {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.extend()
+
+	values := m.finished.clone()
+	minCount, maxCount := m.finishedMin, m.finishedMax
+
+	if values.total == 0 || m.currentCount > 0 {
+		if values.total == 0 {
+			minCount, maxCount = m.currentCount, m.currentCount
+		}
+
+		values.add(float64(m.currentCount), 1)
+		minCount = min(minCount, m.currentCount)
+		maxCount = max(maxCount, m.currentCount)
+	}
+
+	return MonitorSummary{
+		Name:  m.name,
+		Kind:  "counter/" + m.window.String(),
+		Count: m.total,
+		Mean:  float64(m.total) / values.total,
+		Min:   float64(minCount),
+		Max:   float64(maxCount),
+		P50:   values.percentile(50),
+		P95:   values.percentile(95),
+		P99:   values.percentile(99),
+	}
+}
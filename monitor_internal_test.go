@@ -0,0 +1,47 @@
+package chrono
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWeightedValuesBoundedMemory(t *testing.T) {
+	t.Parallel()
+
+	v := newWeightedValues()
+
+	for i := 1; i <= 1_000_000; i++ {
+		v.add(float64(i)/1000, 1)
+	}
+
+	require.True(t, v.approximate)
+	// Values span 6 orders of magnitude
+	require.LessOrEqual(t, len(v.weights), 6*116)
+	require.Equal(t, 1_000_000.0, v.total)
+}
+
+func TestCounterMonitorBoundedMemory(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := NewSimulator(start)
+	m := NewCounterMonitor(s, "requests", time.Second)
+
+	s.EveryFunc(time.Hour, func(now time.Time) bool {
+		m.Inc()
+		return now.Before(start.Add(365 * 24 * time.Hour))
+	})
+
+	s.ProcessAll(context.Background())
+
+	summary := m.Summary()
+	require.Equal(t, 8760, summary.Count)
+	require.Equal(t, 0.0, summary.Min)
+	require.Equal(t, 1.0, summary.Max)
+	require.InDelta(t, 1.0/3600, summary.Mean, 1e-9)
+	// Windows of a year are folded into two distinct counts
+	require.Len(t, m.finished.weights, 2)
+}
@@ -0,0 +1,169 @@
+package chrono_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelMonitor(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	monitors := chrono.NewMonitors(s)
+	queue := monitors.Level("queue", 0)
+
+	require.Equal(t, 0.0, queue.Mean())
+
+	s.AfterFunc(10*time.Second, func(now time.Time) {
+		queue.Set(2)
+	})
+	s.AfterFunc(20*time.Second, func(now time.Time) {
+		queue.Add(2)
+	})
+	s.AfterFunc(40*time.Second, func(now time.Time) {})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, 4.0, queue.Value())
+	require.InDelta(t, 2.5, queue.Mean(), 1e-9)
+	require.Equal(t, 0.0, queue.Min())
+	require.Equal(t, 4.0, queue.Max())
+	require.Equal(t, 2.0, queue.Percentile(50))
+	require.Equal(t, 4.0, queue.Percentile(95))
+	require.Equal(t, 40*time.Second, queue.Duration())
+	require.Equal(t, []chrono.HistogramBucket{
+		{UpperBound: 1, Fraction: 0.25},
+		{UpperBound: 3, Fraction: 0.25},
+		{UpperBound: math.Inf(1), Fraction: 0.5},
+	}, queue.Histogram([]float64{3, 1}))
+
+	summary := queue.Summary()
+	require.Equal(t, 2, summary.Count)
+	require.InDelta(t, 2.5, summary.Mean, 1e-9)
+	require.Equal(t, 4.0, summary.P99)
+}
+
+func TestTallyMonitor(t *testing.T) {
+	t.Parallel()
+
+	m := chrono.NewTallyMonitor("wait")
+	require.True(t, math.IsNaN(m.Mean()))
+	require.True(t, math.IsNaN(m.Percentile(50)))
+
+	for i := 100; i >= 1; i-- {
+		m.Observe(float64(i))
+	}
+
+	require.Equal(t, 100, m.Count())
+	require.InDelta(t, 50.5, m.Mean(), 1e-9)
+	require.InDelta(t, math.Sqrt((100*100-1)/12.0), m.StdDev(), 1e-9)
+	require.Equal(t, 1.0, m.Min())
+	require.Equal(t, 100.0, m.Max())
+	require.Equal(t, 50.0, m.Percentile(50))
+	require.Equal(t, 95.0, m.Percentile(95))
+	require.Equal(t, 99.0, m.Percentile(99))
+	require.Equal(t, []chrono.HistogramBucket{
+		{UpperBound: 50, Fraction: 0.5},
+		{UpperBound: math.Inf(1), Fraction: 0.5},
+	}, m.Histogram([]float64{50}))
+}
+
+func TestCounterMonitor(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	monitors := chrono.NewMonitors(s)
+	requests := monitors.Counter("requests", time.Second)
+	wait := monitors.Tally("wait")
+
+	s.AfterFunc(500*time.Millisecond, func(now time.Time) {
+		requests.Add(2)
+		wait.ObserveDuration(time.Second)
+	})
+	s.AfterFunc(1500*time.Millisecond, func(now time.Time) {
+		requests.Inc()
+		wait.ObserveDuration(3 * time.Second)
+	})
+	s.AfterFunc(3200*time.Millisecond, func(now time.Time) {
+		requests.Add(3)
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, 6, requests.Total())
+	require.Equal(t, chrono.CounterWindow{Start: start.Add(3 * time.Second), Count: 3}, requests.CurrentWindow())
+
+	report := monitors.Report()
+	require.Equal(t, chrono.MonitorsReport{
+		{Name: "requests", Kind: "counter/1s", Count: 6, Mean: 1.5, Min: 0, Max: 3, P50: 1, P95: 3, P99: 3},
+		{Name: "wait", Kind: "tally", Count: 2, Mean: 2, Min: 1, Max: 3, P50: 1, P95: 3, P99: 3},
+	}, report)
+
+	require.Contains(t, report.String(), "requests  counter/1s  6")
+}
+
+func TestTallyMonitorManyValues(t *testing.T) {
+	t.Parallel()
+
+	m := chrono.NewTallyMonitor("wait")
+
+	for i := 1; i <= 100000; i++ {
+		m.ObserveDuration(time.Duration(i) * time.Millisecond)
+	}
+
+	require.InDelta(t, 50.0005, m.Mean(), 1e-9)
+	require.Equal(t, 0.001, m.Min())
+	require.Equal(t, 100.0, m.Max())
+	require.InEpsilon(t, 50, m.Percentile(50), 0.01)
+	require.InEpsilon(t, 95, m.Percentile(95), 0.01)
+	require.InEpsilon(t, 99, m.Percentile(99), 0.01)
+
+	hist := m.Histogram([]float64{10})
+	require.InDelta(t, 0.1, hist[0].Fraction, 0.002)
+	require.InDelta(t, 0.9, hist[1].Fraction, 0.002)
+}
+
+func TestMonitorsReportOnProcessingDone(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	monitors := chrono.NewMonitors(s)
+	queue := monitors.Level("queue", 0)
+	wait := monitors.Tally("wait")
+
+	var reports []chrono.MonitorsReport
+	stop := monitors.ReportOnProcessingDone(s, func(report chrono.MonitorsReport) {
+		reports = append(reports, report)
+	})
+
+	s.AfterFunc(10*time.Second, func(now time.Time) {
+		queue.Set(1)
+		wait.ObserveDuration(time.Second)
+	})
+	s.AfterFunc(20*time.Second, func(now time.Time) {})
+
+	s.ProcessAll(context.Background())
+
+	require.Len(t, reports, 1)
+	require.Len(t, reports[0], 2)
+	require.Equal(t, "queue", reports[0][0].Name)
+	// Calculated up to the end of the processing
+	require.InDelta(t, 0.5, reports[0][0].Mean, 1e-9)
+	require.Equal(t, 1, reports[0][1].Count)
+
+	s.AdvanceBy(context.Background(), 20*time.Second)
+	require.Len(t, reports, 2)
+	require.InDelta(t, 0.75, reports[1][0].Mean, 1e-9)
+
+	stop()
+	s.ProcessAll(context.Background())
+	require.Len(t, reports, 2)
+}
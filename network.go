@@ -0,0 +1,317 @@
+package chrono
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Latency returns delay of a message delivery. It must use only provided random generator,
+// so the simulation is reproducible.
+type Latency func(rnd *rand.Rand) time.Duration
+
+func FixedLatency(d time.Duration) Latency {
+	return func(_ *rand.Rand) time.Duration {
+		return d
+	}
+}
+
+// Latency uniformly distributed in [from, to).
+func UniformLatency(from, to time.Duration) Latency {
+	if to <= from {
+		return FixedLatency(from)
+	}
+
+	return func(rnd *rand.Rand) time.Duration {
+		return from + time.Duration(rnd.Int63n(int64(to-from)))
+	}
+}
+
+// Normally distributed latency. Negative values are replaced with zero.
+func NormalLatency(mean, stddev time.Duration) Latency {
+	return func(rnd *rand.Rand) time.Duration {
+		return max(mean+time.Duration(rnd.NormFloat64()*float64(stddev)), 0)
+	}
+}
+
+type LinkOpts struct {
+	// Delay of the delivery. Zero latency is used if nil.
+	Latency Latency
+	// Probability of the message to be lost.
+	DropRate float64
+	// Probability of the message to ignore the order of sending and be delivered after its own latency.
+	// Otherwise messages of the same link are delivered in the order they were sent,
+	// and a message waits for delivery of the previous ones.
+	ReorderRate float64
+}
+
+type NetworkOpts struct {
+	// Seed of the random generator, which is used for latencies, drops and reordering.
+	Seed int64
+	// Options of the links, which have no options set with SetLinkOpts.
+	DefaultLink LinkOpts
+}
+
+type NetworkStats struct {
+	Sent      int
+	Delivered int
+	// Lost by drop rate, because of partition, or because receiving node was down.
+	Dropped int
+}
+
+var ErrUnknownEndpoint = errors.New("unknown endpoint")
+
+// NewNetwork creates in-memory network, which delivers messages between endpoints in simulated time.
+// Deliveries are scheduled on the simulator, so with the same seed the network behaves the same way every run.
+// Network must be used from the simulator goroutine - the order of sending defines the random values.
+func NewNetwork(s *Simulator, opts NetworkOpts) *Network {
+	return &Network{
+		sim:       s,
+		rnd:       rand.New(rand.NewSource(opts.Seed)),
+		opts:      opts,
+		endpoints: make(map[string]*Endpoint),
+		links:     make(map[linkKey]*link),
+		blocked:   make(map[linkKey]struct{}),
+	}
+}
+
+type Network struct {
+	sim *Simulator
+
+	lock      sync.Mutex
+	rnd       *rand.Rand
+	opts      NetworkOpts
+	endpoints map[string]*Endpoint
+	links     map[linkKey]*link
+	blocked   map[linkKey]struct{}
+	// Partition group of each endpoint. Endpoints, which are not listed in partition, are in group 0.
+	partition map[string]int
+	stats     NetworkStats
+}
+
+type linkKey struct {
+	from, to string
+}
+
+type link struct {
+	opts    *LinkOpts
+	ordered []*message
+	// Delivery time of the last ordered message.
+	lastDelivery time.Time
+}
+
+type message struct {
+	from, to string
+	payload  any
+}
+
+// Handler of incoming messages. Receives simulated time of the delivery.
+type MessageHandler func(now time.Time, from string, msg any)
+
+// NewEndpoint registers endpoint with specified name.
+func (n *Network) NewEndpoint(name string, handler MessageHandler) *Endpoint {
+	return n.newEndpoint(name, nil, handler)
+}
+
+// NewNodeEndpoint registers endpoint of the node, named after the node.
+// Messages to the node are lost while it is down.
+func (n *Network) NewNodeEndpoint(node *Node, handler MessageHandler) *Endpoint {
+	return n.newEndpoint(node.Name(), node, handler)
+}
+
+func (n *Network) newEndpoint(name string, node *Node, handler MessageHandler) *Endpoint {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if _, exists := n.endpoints[name]; exists {
+		panic(fmt.Sprintf("endpoint %q already exists", name))
+	}
+
+	e := &Endpoint{
+		net:     n,
+		name:    name,
+		node:    node,
+		handler: handler,
+	}
+
+	n.endpoints[name] = e
+
+	return e
+}
+
+// Sets options of the link in one direction.
+func (n *Network) SetLinkOpts(from, to string, opts LinkOpts) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.getLink(from, to).opts = &opts
+}
+
+// Makes messages from one endpoint to another to be lost, including ones already sent. Other direction is not affected.
+func (n *Network) BlockLink(from, to string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.blocked[linkKey{from, to}] = struct{}{}
+}
+
+func (n *Network) UnblockLink(from, to string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	delete(n.blocked, linkKey{from, to})
+}
+
+// Partition splits the network into groups. Messages between endpoints of different groups are lost,
+// including ones already sent. Endpoints, which are not listed, form one more group together.
+// Replaces previous partition.
+func (n *Network) Partition(groups ...[]string) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.partition = make(map[string]int)
+
+	for i, group := range groups {
+		for _, name := range group {
+			n.partition[name] = i + 1
+		}
+	}
+}
+
+// Heal removes partition. Blocked links stay blocked.
+func (n *Network) Heal() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.partition = nil
+}
+
+func (n *Network) Stats() NetworkStats {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.stats
+}
+
+// Must be called under lock.
+func (n *Network) getLink(from, to string) *link {
+	key := linkKey{from, to}
+
+	l := n.links[key]
+	if l == nil {
+		l = &link{}
+		n.links[key] = l
+	}
+
+	return l
+}
+
+// Must be called under lock.
+func (n *Network) connected(from, to string) bool {
+	if _, blocked := n.blocked[linkKey{from, to}]; blocked {
+		return false
+	}
+
+	return n.partition[from] == n.partition[to]
+}
+
+func (n *Network) send(from, to string, payload any) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if _, exists := n.endpoints[to]; !exists {
+		return fmt.Errorf("%w: %q", ErrUnknownEndpoint, to)
+	}
+
+	n.stats.Sent++
+
+	l := n.getLink(from, to)
+
+	opts := &n.opts.DefaultLink
+	if l.opts != nil {
+		opts = l.opts
+	}
+
+	// Random values are taken in the same order regardless of the outcome, so that changing e.g. partitions
+	// does not change the randomness of the following messages.
+	dropRoll := n.rnd.Float64()
+	reorderRoll := n.rnd.Float64()
+
+	var latency time.Duration
+	if opts.Latency != nil {
+		latency = max(opts.Latency(n.rnd), 0)
+	}
+
+	if dropRoll < opts.DropRate || !n.connected(from, to) {
+		n.stats.Dropped++
+		return nil
+	}
+
+	msg := &message{from: from, to: to, payload: payload}
+	deliverAt := n.sim.Now().Add(latency)
+
+	if reorderRoll < opts.ReorderRate {
+		n.sim.UntilFunc(deliverAt, func(now time.Time) {
+			n.deliver(now, msg)
+		})
+
+		return nil
+	}
+
+	// Simulator does not keep the order of tasks with equal deadlines,
+	// so each delivery takes the oldest message from the queue of the link.
+	if deliverAt.Before(l.lastDelivery) {
+		deliverAt = l.lastDelivery
+	}
+
+	l.lastDelivery = deliverAt
+	l.ordered = append(l.ordered, msg)
+
+	n.sim.UntilFunc(deliverAt, func(now time.Time) {
+		n.lock.Lock()
+		msg := l.ordered[0]
+		l.ordered[0] = nil
+		l.ordered = l.ordered[1:]
+		n.lock.Unlock()
+
+		n.deliver(now, msg)
+	})
+
+	return nil
+}
+
+func (n *Network) deliver(now time.Time, msg *message) {
+	n.lock.Lock()
+	e := n.endpoints[msg.to]
+
+	if !n.connected(msg.from, msg.to) || (e.node != nil && !e.node.Up()) {
+		n.stats.Dropped++
+		n.lock.Unlock()
+
+		return
+	}
+
+	n.stats.Delivered++
+	n.lock.Unlock()
+
+	e.handler(now, msg.from, msg.payload)
+}
+
+type Endpoint struct {
+	net     *Network
+	name    string
+	node    *Node
+	handler MessageHandler
+}
+
+func (e *Endpoint) Name() string {
+	return e.name
+}
+
+// Send schedules delivery of the message. Lost messages are not reported.
+// Returns ErrUnknownEndpoint if there is no endpoint with such name.
+func (e *Endpoint) Send(to string, msg any) error {
+	return e.net.send(e.name, to, msg)
+}
@@ -0,0 +1,135 @@
+package chrono_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkOrderingAndDeterminism(t *testing.T) {
+	t.Parallel()
+
+	run := func(reorderRate float64) []string {
+		start := time.Now()
+		s := chrono.NewSimulator(start)
+		n := chrono.NewNetwork(s, chrono.NetworkOpts{
+			Seed: 42,
+			DefaultLink: chrono.LinkOpts{
+				Latency:     chrono.UniformLatency(time.Millisecond, 100*time.Millisecond),
+				DropRate:    0.2,
+				ReorderRate: reorderRate,
+			},
+		})
+
+		var res []string
+
+		a := n.NewEndpoint("a", nil)
+		n.NewEndpoint("b", func(now time.Time, from string, msg any) {
+			res = append(res, fmt.Sprintf("%v from %v", msg, from))
+		})
+
+		s.EveryFunc(time.Millisecond, func(now time.Time) bool {
+			require.NoError(t, a.Send("b", len(res)+int(now.Sub(start)/time.Millisecond)*1000))
+			return now.Sub(start) < 50*time.Millisecond
+		})
+
+		s.ProcessAll(context.Background())
+
+		stats := n.Stats()
+		require.Equal(t, 50, stats.Sent)
+		require.Equal(t, stats.Sent, stats.Delivered+stats.Dropped)
+		require.Len(t, res, stats.Delivered)
+		require.NotZero(t, stats.Dropped)
+
+		return res
+	}
+
+	ordered := run(0)
+	require.Equal(t, ordered, run(0))
+	require.IsIncreasing(t, parseSeq(t, ordered))
+
+	reordered := run(1)
+	require.Equal(t, reordered, run(1))
+	require.NotEqual(t, ordered, reordered)
+}
+
+func parseSeq(t *testing.T, msgs []string) []int {
+	var res []int
+
+	for _, msg := range msgs {
+		var seq int
+		var from string
+		_, err := fmt.Sscanf(msg, "%d from %s", &seq, &from)
+		require.NoError(t, err)
+		res = append(res, seq)
+	}
+
+	return res
+}
+
+func TestNetworkPartitions(t *testing.T) {
+	t.Parallel()
+
+	s := chrono.NewSimulator(time.Now())
+	n := chrono.NewNetwork(s, chrono.NetworkOpts{
+		DefaultLink: chrono.LinkOpts{Latency: chrono.FixedLatency(time.Second)},
+	})
+
+	var res []string
+
+	handler := func(name string) chrono.MessageHandler {
+		return func(now time.Time, from string, msg any) {
+			res = append(res, fmt.Sprintf("%v: %v from %v", name, msg, from))
+		}
+	}
+
+	nodeC := s.NewNode("c", chrono.NodeOpts{})
+
+	a := n.NewEndpoint("a", handler("a"))
+	b := n.NewEndpoint("b", handler("b"))
+	n.NewNodeEndpoint(nodeC, handler("c"))
+
+	require.ErrorIs(t, a.Send("unknown", "msg"), chrono.ErrUnknownEndpoint)
+
+	s.AfterFunc(0, func(now time.Time) {
+		a.Send("b", "1")
+		a.Send("c", "1")
+		n.Partition([]string{"a"}) // Message in flight is lost
+	})
+
+	s.AfterFunc(2*time.Second, func(now time.Time) {
+		a.Send("b", "2")
+		b.Send("c", "2")
+		n.Heal()
+		n.BlockLink("b", "a")
+		nodeC.Crash()
+	})
+
+	s.AfterFunc(4*time.Second, func(now time.Time) {
+		a.Send("b", "3")
+		b.Send("a", "3")
+		a.Send("c", "3")
+	})
+
+	s.AfterFunc(6*time.Second, func(now time.Time) {
+		nodeC.Restart()
+		n.UnblockLink("b", "a")
+		n.SetLinkOpts("b", "a", chrono.LinkOpts{Latency: chrono.FixedLatency(time.Minute)})
+		b.Send("a", "4")
+		a.Send("c", "4")
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []string{
+		"b: 3 from a",
+		"c: 4 from a",
+		"a: 4 from b",
+	}, res)
+
+	require.Equal(t, chrono.NetworkStats{Sent: 9, Delivered: 3, Dropped: 6}, n.Stats())
+}
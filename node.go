@@ -0,0 +1,147 @@
+package chrono
+
+import (
+	"sync"
+	"time"
+)
+
+type NodeOpts struct {
+	// Constant offset of the node clock from the simulator time.
+	Offset time.Duration
+	// Drift of the node clock, see NewSkewedClock.
+	DriftRate float64
+}
+
+// NewNode creates a virtual node - clock view for one of many services simulated in the same process.
+// All the nodes are backed by the same simulator, so the order of their tasks is deterministic.
+// Each node has its own skew (see NewSkewedClock) and its own scope of timers and tickers (see NewScopedClock).
+// Child scopes created with NewChild share the skew and the lifecycle of the node.
+//
+// Crash stops all the timers and tickers of the node. Handlers of the previous incarnation of the node
+// never fire again - even if their timers are reset or were scheduled while the node was down.
+func (s *Simulator) NewNode(name string, opts NodeOpts) *Node {
+	n := &Node{
+		name: name,
+		skew: NewSkewedClock(s, opts.Offset, opts.DriftRate),
+	}
+
+	n.ScopedClock = NewScopedClock(&nodeClock{Clock: n.skew, node: n})
+
+	return n
+}
+
+type Node struct {
+	*ScopedClock
+
+	name string
+	skew *SkewedClock
+
+	lock        sync.Mutex
+	down        bool
+	incarnation uint64
+}
+
+var _ Clock = &Node{}
+
+func (n *Node) Name() string {
+	return n.name
+}
+
+// Skew returns the clock of the node, which can be used to change its time with Step and AddStep.
+// Timers scheduled directly through it are not tracked by the node.
+func (n *Node) Skew() *SkewedClock {
+	return n.skew
+}
+
+// Crash makes the node down and drops all of its pending timers and tickers.
+func (n *Node) Crash() {
+	n.lock.Lock()
+	n.down = true
+	n.incarnation++
+	n.lock.Unlock()
+
+	n.StopAll()
+}
+
+// Restart brings crashed node up. Timers of the previous incarnation are not restored.
+func (n *Node) Restart() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if !n.down {
+		return
+	}
+
+	n.down = false
+	n.incarnation++
+}
+
+// Returns true if the node is not crashed.
+func (n *Node) Up() bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return !n.down
+}
+
+// Returns the current incarnation of the node and whether it is up.
+func (n *Node) currentIncarnation() (incarnation uint64, up bool) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.incarnation, !n.down
+}
+
+// Returns true if the node was not crashed or restarted since the incarnation.
+func (n *Node) isAlive(incarnation uint64) bool {
+	current, up := n.currentIncarnation()
+	return up && current == incarnation
+}
+
+// Clock, which ignores handlers of the tasks from the previous incarnations of the node.
+type nodeClock struct {
+	Clock
+	node *Node
+}
+
+func (c *nodeClock) AfterFunc(d time.Duration, f func(now time.Time)) Timer {
+	return c.Clock.AfterFunc(d, c.wrap(f))
+}
+
+func (c *nodeClock) UntilFunc(t time.Time, f func(now time.Time)) Timer {
+	return c.Clock.UntilFunc(t, c.wrap(f))
+}
+
+func (c *nodeClock) EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker {
+	incarnation, _ := c.node.currentIncarnation()
+
+	return c.Clock.EveryFunc(d, func(now time.Time) bool {
+		if !c.node.isAlive(incarnation) {
+			return false
+		}
+
+		return f(now)
+	})
+}
+
+func (c *nodeClock) RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	incarnation, _ := c.node.currentIncarnation()
+
+	return RepeatFunc(c.Clock, d, func(now time.Time) (time.Duration, bool) {
+		if !c.node.isAlive(incarnation) {
+			return 0, false
+		}
+
+		return f(now)
+	})
+}
+
+func (c *nodeClock) wrap(f func(now time.Time)) func(now time.Time) {
+	incarnation, _ := c.node.currentIncarnation()
+
+	return func(now time.Time) {
+		if c.node.isAlive(incarnation) {
+			f(now)
+		}
+	}
+}
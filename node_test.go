@@ -0,0 +1,76 @@
+package chrono_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+
+	a := s.NewNode("a", chrono.NodeOpts{Offset: time.Second})
+	b := s.NewNode("b", chrono.NodeOpts{Offset: -time.Second})
+	child := b.NewChild()
+
+	require.Equal(t, "a", a.Name())
+	require.Equal(t, start.Add(time.Second), a.Now())
+	require.Equal(t, start.Add(-time.Second), b.Now())
+
+	var res []string
+
+	a.EveryFunc(time.Minute, func(now time.Time) bool {
+		res = append(res, "a tick")
+		return true
+	})
+
+	b.AfterFunc(90*time.Second, func(now time.Time) {
+		res = append(res, "b timer")
+	})
+
+	child.AfterFunc(2*time.Minute, func(now time.Time) {
+		res = append(res, "b child timer")
+	})
+
+	bTimer := b.AfterFunc(3*time.Minute, func(now time.Time) {
+		res = append(res, "b old timer")
+	})
+
+	s.AfterFunc(100*time.Second, func(now time.Time) {
+		b.Crash()
+		require.False(t, b.Up())
+
+		// Scheduled while the node is down - never fires
+		b.AfterFunc(time.Second, func(now time.Time) {
+			res = append(res, "b timer while down")
+		})
+	})
+
+	s.AfterFunc(150*time.Second, func(now time.Time) {
+		b.Restart()
+		require.True(t, b.Up())
+
+		// Timer of previous incarnation is not revived
+		bTimer.Reset(time.Second)
+
+		b.AfterFunc(10*time.Second, func(now time.Time) {
+			res = append(res, "b restarted timer")
+		})
+	})
+
+	s.ProcessAllUntil(context.Background(), start.Add(170*time.Second))
+
+	require.Equal(t, []string{
+		"a tick",
+		"b timer",
+		"a tick",
+		"b restarted timer",
+	}, res)
+	require.Equal(t, 0, child.Len())
+}
@@ -0,0 +1,115 @@
+package chrono
+
+import "time"
+
+// NewOffsetClock creates clock, which reports time of the underlying clock shifted by the offset,
+// while running at the same speed. Useful to run live code as if it was at some other date.
+// Absolute deadlines (UntilFunc, ResetAt, Deadline) and the time passed to handlers are shifted as well.
+func NewOffsetClock(c Clock, offset time.Duration) *OffsetClock {
+	return &OffsetClock{
+		clock:  c,
+		offset: offset,
+	}
+}
+
+// NewOffsetClockAt creates offset clock, which current time is the specified moment.
+func NewOffsetClockAt(c Clock, now time.Time) *OffsetClock {
+	return NewOffsetClock(c, now.Sub(c.Now()))
+}
+
+type OffsetClock struct {
+	clock  Clock
+	offset time.Duration
+}
+
+var _ Clock = &OffsetClock{}
+
+func (c *OffsetClock) Offset() time.Duration {
+	return c.offset
+}
+
+func (c *OffsetClock) Now() time.Time {
+	return c.clock.Now().Add(c.offset)
+}
+
+func (c *OffsetClock) Since(t time.Time) time.Duration {
+	return c.clock.Since(t.Add(-c.offset))
+}
+
+func (c *OffsetClock) Until(t time.Time) time.Duration {
+	return c.clock.Until(t.Add(-c.offset))
+}
+
+func (c *OffsetClock) AfterFunc(d time.Duration, f func(now time.Time)) Timer {
+	return &offsetTimer{
+		Timer:  c.clock.AfterFunc(d, c.wrap(f)),
+		offset: c.offset,
+	}
+}
+
+func (c *OffsetClock) UntilFunc(t time.Time, f func(now time.Time)) Timer {
+	return &offsetTimer{
+		Timer:  c.clock.UntilFunc(t.Add(-c.offset), c.wrap(f)),
+		offset: c.offset,
+	}
+}
+
+func (c *OffsetClock) EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker {
+	return &offsetTicker{
+		Ticker: c.clock.EveryFunc(d, func(now time.Time) bool {
+			return f(now.Add(c.offset))
+		}),
+		offset: c.offset,
+	}
+}
+
+func (c *OffsetClock) RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	return &offsetTicker{
+		Ticker: RepeatFunc(c.clock, d, func(now time.Time) (time.Duration, bool) {
+			return f(now.Add(c.offset))
+		}),
+		offset: c.offset,
+	}
+}
+
+func (c *OffsetClock) wrap(f func(now time.Time)) func(now time.Time) {
+	return func(now time.Time) {
+		f(now.Add(c.offset))
+	}
+}
+
+type offsetTimer struct {
+	Timer
+	offset time.Duration
+}
+
+func (t *offsetTimer) ResetAt(deadline time.Time) bool {
+	return t.Timer.ResetAt(deadline.Add(-t.offset))
+}
+
+func (t *offsetTimer) Deadline() time.Time {
+	deadline := t.Timer.Deadline()
+	if deadline.IsZero() {
+		return deadline
+	}
+
+	return deadline.Add(t.offset)
+}
+
+type offsetTicker struct {
+	Ticker
+	offset time.Duration
+}
+
+func (t *offsetTicker) ResetAt(deadline time.Time) {
+	t.Ticker.ResetAt(deadline.Add(-t.offset))
+}
+
+func (t *offsetTicker) Deadline() time.Time {
+	deadline := t.Ticker.Deadline()
+	if deadline.IsZero() {
+		return deadline
+	}
+
+	return deadline.Add(t.offset)
+}
@@ -0,0 +1,69 @@
+package chrono_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOffsetClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	blackFriday := time.Date(2019, 11, 29, 9, 0, 0, 0, time.UTC)
+
+	s := chrono.NewSimulator(start)
+	c := chrono.NewOffsetClockAt(s, blackFriday)
+
+	require.Equal(t, blackFriday, c.Now())
+	require.Equal(t, time.Hour, c.Until(blackFriday.Add(time.Hour)))
+	require.Equal(t, time.Hour, c.Since(blackFriday.Add(-time.Hour)))
+
+	var res []time.Time
+
+	timer := c.UntilFunc(blackFriday.Add(time.Hour), func(now time.Time) {
+		res = append(res, now)
+	})
+	require.Equal(t, blackFriday.Add(time.Hour), timer.Deadline())
+
+	timer.ResetAt(blackFriday.Add(2 * time.Hour))
+	require.Equal(t, blackFriday.Add(2*time.Hour), timer.Deadline())
+
+	c.EveryFunc(time.Hour, func(now time.Time) bool {
+		res = append(res, now)
+		return false
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []time.Time{blackFriday.Add(time.Hour), blackFriday.Add(2 * time.Hour)}, res)
+	require.Equal(t, start.Add(2*time.Hour), s.Now())
+}
+
+func TestOffsetClockWithBuffering(t *testing.T) {
+	t.Parallel()
+
+	blackFriday := time.Date(2019, 11, 29, 9, 0, 0, 0, time.UTC)
+	offsetClock := chrono.NewOffsetClockAt(chrono.NewRealClock(), blackFriday)
+	c := chrono.NewClockWithBuffering(offsetClock)
+
+	c.BeginTasksBuffering(blackFriday.Add(-2 * time.Hour))
+
+	bufferedAt := make(chan time.Time, 1)
+	liveAt := make(chan time.Time, 1)
+
+	c.UntilFunc(blackFriday.Add(-time.Hour), func(now time.Time) {
+		bufferedAt <- now
+	})
+
+	c.UntilFunc(blackFriday.Add(50*time.Millisecond), func(now time.Time) {
+		liveAt <- now
+	})
+
+	require.NoError(t, c.EndTasksBuffering(context.Background(), offsetClock.Now))
+	require.Equal(t, blackFriday.Add(-time.Hour), <-bufferedAt)
+	require.WithinDuration(t, blackFriday.Add(50*time.Millisecond), <-liveAt, time.Second)
+}
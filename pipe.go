@@ -0,0 +1,446 @@
+package chrono
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+type PipeOpts struct {
+	// Delay between sending data and its availability for reading.
+	Latency time.Duration
+	// Bytes per second. Data of each direction is transferred one write after another. Zero means unlimited.
+	Bandwidth int
+}
+
+var ErrConnectionRefused = errors.New("connection refused")
+
+// Pipe creates pair of connected in-memory connections. Unlike net.Pipe, deadlines, latency and bandwidth
+// are evaluated using the clock, so code working with net.Conn can be run in simulated time.
+//
+// Writes do not block - written data is buffered until it is read.
+// Reads block the calling goroutine, so with Simulator connections must be used from other goroutines.
+// Use Simulator.Hold to prevent simulated time from running ahead while those goroutines are busy.
+func Pipe(c Clock, opts PipeOpts) (net.Conn, net.Conn) {
+	return newPipe(c, opts, pipeAddr("pipe"), pipeAddr("pipe"))
+}
+
+func newPipe(c Clock, opts PipeOpts, addr1, addr2 pipeAddr) (*pipeConn, *pipeConn) {
+	forward := newPipeHalf(c, opts)
+	backward := newPipeHalf(c, opts)
+
+	conn1 := &pipeConn{
+		read:          backward,
+		write:         forward,
+		readDeadline:  newConnDeadline(c),
+		writeDeadline: newConnDeadline(c),
+		local:         addr1,
+		remote:        addr2,
+	}
+
+	conn2 := &pipeConn{
+		read:          forward,
+		write:         backward,
+		readDeadline:  newConnDeadline(c),
+		writeDeadline: newConnDeadline(c),
+		local:         addr2,
+		remote:        addr1,
+	}
+
+	return conn1, conn2
+}
+
+type pipeAddr string
+
+func (a pipeAddr) Network() string {
+	return "chrono"
+}
+
+func (a pipeAddr) String() string {
+	return string(a)
+}
+
+type pipeConn struct {
+	read          *pipeHalf
+	write         *pipeHalf
+	readDeadline  *connDeadline
+	writeDeadline *connDeadline
+	local         pipeAddr
+	remote        pipeAddr
+
+	closeOnce sync.Once
+}
+
+var _ net.Conn = &pipeConn{}
+
+func (c *pipeConn) Read(b []byte) (int, error) {
+	return c.read.Read(b, c.readDeadline)
+}
+
+func (c *pipeConn) Write(b []byte) (int, error) {
+	return c.write.Write(b, c.writeDeadline)
+}
+
+// Close makes local reads and writes fail with net.ErrClosed.
+// Remote side reads the data, which is already sent, and then gets io.EOF.
+func (c *pipeConn) Close() error {
+	err := net.ErrClosed
+
+	c.closeOnce.Do(func() {
+		err = nil
+
+		c.read.closeReader()
+		c.write.closeWriter()
+		c.readDeadline.set(time.Time{})
+		c.writeDeadline.set(time.Time{})
+	})
+
+	return err
+}
+
+func (c *pipeConn) LocalAddr() net.Addr {
+	return c.local
+}
+
+func (c *pipeConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+func (c *pipeConn) SetDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	c.writeDeadline.set(t)
+
+	return nil
+}
+
+func (c *pipeConn) SetReadDeadline(t time.Time) error {
+	c.readDeadline.set(t)
+	return nil
+}
+
+func (c *pipeConn) SetWriteDeadline(t time.Time) error {
+	c.writeDeadline.set(t)
+	return nil
+}
+
+// One direction of the pipe.
+type pipeHalf struct {
+	clock Clock
+	opts  PipeOpts
+
+	lock sync.Mutex
+	// Closed and replaced on each change of the state, to wake up waiting readers.
+	changed  chan struct{}
+	readable []byte
+	inFlight [][]byte
+	// Moment when the transfer of the last written data is finished.
+	busyUntil    time.Time
+	writerClosed bool
+	readerClosed bool
+}
+
+func newPipeHalf(c Clock, opts PipeOpts) *pipeHalf {
+	return &pipeHalf{
+		clock:   c,
+		opts:    opts,
+		changed: make(chan struct{}),
+	}
+}
+
+func (h *pipeHalf) Read(b []byte, deadline *connDeadline) (int, error) {
+	for {
+		exceeded, deadlineChanged := deadline.state()
+
+		h.lock.Lock()
+
+		switch {
+		case h.readerClosed:
+			h.lock.Unlock()
+			return 0, net.ErrClosed
+		case exceeded:
+			h.lock.Unlock()
+			return 0, os.ErrDeadlineExceeded
+		case len(b) == 0:
+			h.lock.Unlock()
+			return 0, nil
+		case len(h.readable) > 0:
+			n := copy(b, h.readable)
+			h.readable = h.readable[n:]
+			h.lock.Unlock()
+
+			return n, nil
+		case h.writerClosed && len(h.inFlight) == 0:
+			h.lock.Unlock()
+			return 0, io.EOF
+		}
+
+		changed := h.changed
+		h.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadlineChanged:
+		}
+	}
+}
+
+func (h *pipeHalf) Write(b []byte, deadline *connDeadline) (int, error) {
+	exceeded, _ := deadline.state()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	switch {
+	case h.writerClosed:
+		return 0, net.ErrClosed
+	case h.readerClosed:
+		return 0, io.ErrClosedPipe
+	case exceeded:
+		return 0, os.ErrDeadlineExceeded
+	case len(b) == 0:
+		return 0, nil
+	}
+
+	if h.opts.Latency <= 0 && h.opts.Bandwidth <= 0 {
+		h.readable = append(h.readable, b...)
+		h.notifyChanged()
+
+		return len(b), nil
+	}
+
+	transferStart := h.clock.Now()
+	if transferStart.Before(h.busyUntil) {
+		transferStart = h.busyUntil
+	}
+
+	h.busyUntil = transferStart
+	if h.opts.Bandwidth > 0 {
+		h.busyUntil = h.busyUntil.Add(time.Duration(float64(len(b)) / float64(h.opts.Bandwidth) * float64(time.Second)))
+	}
+
+	h.inFlight = append(h.inFlight, append([]byte(nil), b...))
+
+	// Clock may not keep the order of tasks with equal deadlines,
+	// so each delivery takes the oldest data in flight.
+	h.clock.UntilFunc(h.busyUntil.Add(h.opts.Latency), func(now time.Time) {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+
+		data := h.inFlight[0]
+		h.inFlight[0] = nil
+		h.inFlight = h.inFlight[1:]
+
+		if !h.readerClosed {
+			h.readable = append(h.readable, data...)
+		}
+
+		h.notifyChanged()
+	})
+
+	return len(b), nil
+}
+
+func (h *pipeHalf) closeReader() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.readerClosed = true
+	h.readable = nil
+	h.notifyChanged()
+}
+
+func (h *pipeHalf) closeWriter() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.writerClosed = true
+	h.notifyChanged()
+}
+
+// Must be called under lock.
+func (h *pipeHalf) notifyChanged() {
+	close(h.changed)
+	h.changed = make(chan struct{})
+}
+
+// Read or write deadline of the connection, which expires by the clock.
+type connDeadline struct {
+	clock Clock
+
+	lock     sync.Mutex
+	timer    Timer
+	exceeded bool
+	// Incremented on each change of the deadline to ignore outdated timers.
+	generation uint64
+	// Closed and replaced on each change of the state, to wake up waiting readers.
+	changed chan struct{}
+}
+
+func newConnDeadline(c Clock) *connDeadline {
+	return &connDeadline{
+		clock:   c,
+		changed: make(chan struct{}),
+	}
+}
+
+// Zero time means no deadline.
+func (d *connDeadline) set(t time.Time) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+
+	d.generation++
+	d.exceeded = false
+
+	switch {
+	case t.IsZero():
+	case !t.After(d.clock.Now()):
+		d.exceeded = true
+	default:
+		generation := d.generation
+		d.timer = d.clock.UntilFunc(t, func(now time.Time) {
+			d.lock.Lock()
+			defer d.lock.Unlock()
+
+			if d.generation != generation {
+				return
+			}
+
+			d.exceeded = true
+			d.notifyChanged()
+		})
+	}
+
+	d.notifyChanged()
+}
+
+func (d *connDeadline) state() (exceeded bool, changed <-chan struct{}) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.exceeded, d.changed
+}
+
+// Must be called under lock.
+func (d *connDeadline) notifyChanged() {
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// NewListener creates in-memory listener, which connections are created with Pipe semantics.
+// Clients connect to it with Dial or DialContext. DialContext can be used in http.Transport.
+func NewListener(c Clock, addr string, opts PipeOpts) *Listener {
+	return &Listener{
+		clock:   c,
+		addr:    pipeAddr(addr),
+		opts:    opts,
+		changed: make(chan struct{}),
+	}
+}
+
+type Listener struct {
+	clock Clock
+	addr  pipeAddr
+	opts  PipeOpts
+
+	lock    sync.Mutex
+	pending []net.Conn
+	// Closed and replaced when a connection is added or the listener is closed.
+	changed    chan struct{}
+	closed     bool
+	dialsCount int
+}
+
+var _ net.Listener = &Listener{}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		l.lock.Lock()
+
+		if l.closed {
+			l.lock.Unlock()
+			return nil, net.ErrClosed
+		}
+
+		if len(l.pending) > 0 {
+			conn := l.pending[0]
+			l.pending[0] = nil
+			l.pending = l.pending[1:]
+			l.lock.Unlock()
+
+			return conn, nil
+		}
+
+		changed := l.changed
+		l.lock.Unlock()
+
+		<-changed
+	}
+}
+
+// Close makes Accept and Dial fail. Connections, which are not accepted yet, are closed.
+func (l *Listener) Close() error {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return net.ErrClosed
+	}
+
+	l.closed = true
+
+	for _, conn := range l.pending {
+		conn.Close()
+	}
+
+	l.pending = nil
+	l.notifyChanged()
+
+	return nil
+}
+
+func (l *Listener) Addr() net.Addr {
+	return l.addr
+}
+
+// Dial creates connection to the listener. Returns ErrConnectionRefused if the listener is closed.
+func (l *Listener) Dial() (net.Conn, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if l.closed {
+		return nil, fmt.Errorf("dial %v: %w", l.addr, ErrConnectionRefused)
+	}
+
+	l.dialsCount++
+	client, server := newPipe(l.clock, l.opts, pipeAddr(fmt.Sprintf("%v-client-%v", l.addr, l.dialsCount)), l.addr)
+
+	l.pending = append(l.pending, server)
+	l.notifyChanged()
+
+	return client, nil
+}
+
+// Same as Dial. Network and address are ignored - the connection is always made to this listener.
+func (l *Listener) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return l.Dial()
+}
+
+// Must be called under lock.
+func (l *Listener) notifyChanged() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
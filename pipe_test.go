@@ -0,0 +1,276 @@
+package chrono_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+type readResult struct {
+	data []byte
+	err  error
+}
+
+func readAsync(conn net.Conn, size int) <-chan readResult {
+	res := make(chan readResult, 1)
+
+	go func() {
+		buf := make([]byte, size)
+		n, err := io.ReadFull(conn, buf)
+		res <- readResult{data: buf[:n], err: err}
+	}()
+
+	return res
+}
+
+func requireNoResult(t *testing.T, res <-chan readResult) {
+	select {
+	case r := <-res:
+		require.Fail(t, "unexpected read result", "%v", r)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestPipeDeadline(t *testing.T) {
+	t.Parallel()
+
+	s := chrono.NewSimulator(time.Now())
+	a, b := chrono.Pipe(s, chrono.PipeOpts{})
+
+	require.NoError(t, b.SetReadDeadline(s.Now().Add(time.Minute)))
+	res := readAsync(b, 1)
+
+	requireNoResult(t, res)
+
+	s.AdvanceBy(context.Background(), time.Minute)
+
+	r := <-res
+	require.ErrorIs(t, r.err, os.ErrDeadlineExceeded)
+
+	var netErr net.Error
+	require.True(t, errors.As(r.err, &netErr))
+	require.True(t, netErr.Timeout())
+
+	// Reset deadline makes connection usable again
+	require.NoError(t, b.SetReadDeadline(time.Time{}))
+	_, err := a.Write([]byte("x"))
+	require.NoError(t, err)
+	r = <-readAsync(b, 1)
+	require.NoError(t, r.err)
+	require.Equal(t, "x", string(r.data))
+
+	require.NoError(t, a.SetWriteDeadline(s.Now()))
+	_, err = a.Write([]byte("x"))
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+}
+
+func TestPipeLatencyAndBandwidth(t *testing.T) {
+	t.Parallel()
+
+	s := chrono.NewSimulator(time.Now())
+	a, b := chrono.Pipe(s, chrono.PipeOpts{Latency: 100 * time.Millisecond, Bandwidth: 1000})
+
+	_, err := a.Write(make([]byte, 100)) // Transferred in 100ms
+	require.NoError(t, err)
+	_, err = a.Write([]byte("tail")) // Transferred in 4ms after first one
+	require.NoError(t, err)
+	require.NoError(t, a.Close())
+
+	res := readAsync(b, 104)
+
+	s.AdvanceBy(context.Background(), 203*time.Millisecond)
+	requireNoResult(t, res)
+
+	s.AdvanceBy(context.Background(), time.Millisecond)
+	r := <-res
+	require.NoError(t, r.err)
+	require.Equal(t, "tail", string(r.data[100:]))
+
+	r = <-readAsync(b, 1)
+	require.ErrorIs(t, r.err, io.EOF)
+
+	_, err = a.Write([]byte("x"))
+	require.ErrorIs(t, err, net.ErrClosed)
+	_, err = b.Write([]byte("x"))
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
+func TestListenerHTTP(t *testing.T) {
+	t.Parallel()
+
+	c := chrono.NewRealClock()
+	l := chrono.NewListener(c, "server", chrono.PipeOpts{Latency: time.Millisecond})
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello " + r.URL.Path))
+		}),
+	}
+
+	go srv.Serve(l)
+	defer srv.Close()
+
+	client := &http.Client{
+		Transport: &http.Transport{DialContext: l.DialContext},
+	}
+
+	resp, err := client.Get("http://server/world")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "hello /world", string(body))
+
+	require.NoError(t, l.Close())
+	_, err = l.Dial()
+	require.ErrorIs(t, err, chrono.ErrConnectionRefused)
+}
+
+// Clock for running HTTP server and client in simulated time. Simulator is held from the delivery of data
+// until the receiver replies with its own write, so that time does not run ahead and simulation does not finish
+// while goroutines process the data.
+type deliveryHoldingClock struct {
+	*chrono.Simulator
+
+	lock    sync.Mutex
+	release func()
+}
+
+// Pipe schedules deliveries of written data with UntilFunc.
+func (c *deliveryHoldingClock) UntilFunc(t time.Time, f func(now time.Time)) chrono.Timer {
+	timer := c.Simulator.UntilFunc(t, func(now time.Time) {
+		c.hold()
+		f(now)
+	})
+
+	c.releaseHold()
+
+	return timer
+}
+
+func (c *deliveryHoldingClock) hold() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.release == nil {
+		c.release = c.Hold()
+	}
+}
+
+func (c *deliveryHoldingClock) releaseHold() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.release != nil {
+		c.release()
+		c.release = nil
+	}
+}
+
+// Counts bytes sent and received by the client.
+type countingConn struct {
+	net.Conn
+	written atomic.Int64
+	read    atomic.Int64
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.written.Add(int64(n))
+
+	return n, err
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.read.Add(int64(n))
+
+	return n, err
+}
+
+func TestListenerHTTPSimulated(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	c := &deliveryHoldingClock{Simulator: s}
+
+	const latency = 10 * time.Millisecond
+	// Transfer of each byte takes 1ms
+	l := chrono.NewListener(c, "server", chrono.PipeOpts{Latency: latency, Bandwidth: 1000})
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello " + r.URL.Path))
+		}),
+	}
+
+	go srv.Serve(l)
+	defer srv.Close()
+
+	var clientConn *countingConn
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := l.DialContext(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+
+				clientConn = &countingConn{Conn: conn}
+
+				return clientConn, nil
+			},
+		},
+	}
+
+	type response struct {
+		body       string
+		err        error
+		receivedAt time.Time
+	}
+
+	responses := make(chan response, 1)
+
+	s.AfterFunc(time.Minute, func(now time.Time) {
+		// Released by the first delivery, which is scheduled when the request is written
+		c.hold()
+
+		go func() {
+			// Response is received, so simulator can finish
+			defer c.releaseHold()
+
+			resp, err := client.Get("http://server/world")
+			if err != nil {
+				responses <- response{err: err}
+				return
+			}
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			responses <- response{body: string(body), err: err, receivedAt: s.Now()}
+		}()
+	})
+
+	_, err := s.ProcessAll(context.Background())
+	require.NoError(t, err)
+
+	resp := <-responses
+	require.NoError(t, resp.err)
+	require.Equal(t, "hello /world", resp.body)
+
+	// Request and response are sent with a single write each
+	transferred := time.Duration(clientConn.written.Load()+clientConn.read.Load()) * time.Millisecond
+	require.Equal(t, start.Add(time.Minute+2*latency+transferred), resp.receivedAt)
+}
@@ -0,0 +1,455 @@
+package chrono
+
+import (
+	"sync"
+	"time"
+)
+
+// Discrete-event modelling primitives in the style of SimPy. Like SimMutex, they are callback-based,
+// and their callbacks are executed as tasks of the clock in the order the requests are satisfied.
+// Statistics are calculated since the creation.
+
+// Statistics of waiting for requests to be satisfied.
+type WaitStats struct {
+	// Number of satisfied requests.
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+func (s WaitStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+
+	return s.Total / time.Duration(s.Count)
+}
+
+func (s *WaitStats) add(wait time.Duration) {
+	s.Count++
+	s.Total += wait
+	s.Max = max(s.Max, wait)
+}
+
+// NewResource creates resource, which can be used by limited number of users at the same time - e.g. pool of workers.
+// Requests are granted in the order of their priority, and then in the order they were made.
+func NewResource(c Clock, capacity int) *Resource {
+	if capacity <= 0 {
+		panic("non-positive resource capacity")
+	}
+
+	now := c.Now()
+
+	return &Resource{
+		clock:       c,
+		callbacks:   newCallbackQueue(c),
+		capacity:    capacity,
+		usage:       newTimeAverage(now, 0),
+		queueLength: newTimeAverage(now, 0),
+	}
+}
+
+type Resource struct {
+	clock     Clock
+	callbacks callbackQueue
+	capacity  int
+
+	lock        sync.Mutex
+	inUse       int
+	queue       []*ResourceRequest
+	wait        WaitStats
+	usage       timeAverage
+	queueLength timeAverage
+}
+
+type ResourceStats struct {
+	Wait WaitStats
+	// Time-weighted average part of the capacity, which was in use.
+	Utilization float64
+	// Time-weighted average number of waiting requests.
+	MeanQueueLength float64
+	InUse           int
+	QueueLength     int
+}
+
+type ResourceRequest struct {
+	res         *Resource
+	priority    int
+	requestedAt time.Time
+	f           func(now time.Time)
+	granted     bool
+	finished    bool
+}
+
+// Request calls f once a unit of the resource is granted. The unit must be released with ResourceRequest.Release.
+func (r *Resource) Request(f func(now time.Time)) *ResourceRequest {
+	return r.RequestPriority(0, f)
+}
+
+// RequestPriority is same as Request, but requests with lower priority value are granted first.
+func (r *Resource) RequestPriority(priority int, f func(now time.Time)) *ResourceRequest {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	req := &ResourceRequest{
+		res:         r,
+		priority:    priority,
+		requestedAt: r.clock.Now(),
+		f:           f,
+	}
+
+	i := len(r.queue)
+	for i > 0 && r.queue[i-1].priority > priority {
+		i--
+	}
+
+	r.queue = append(r.queue, nil)
+	copy(r.queue[i+1:], r.queue[i:])
+	r.queue[i] = req
+
+	r.grant()
+
+	return req
+}
+
+// Release returns the unit of the resource. If the request is not granted yet, it is cancelled.
+func (req *ResourceRequest) Release() {
+	r := req.res
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if req.finished {
+		return
+	}
+
+	req.finished = true
+
+	if !req.granted {
+		r.removeFromQueue(req)
+		return
+	}
+
+	r.inUse--
+	r.usage.set(r.clock.Now(), float64(r.inUse))
+	r.grant()
+}
+
+// Cancel removes the request from the queue. Returns false if it was already granted or cancelled.
+func (req *ResourceRequest) Cancel() bool {
+	r := req.res
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if req.finished || req.granted {
+		return false
+	}
+
+	req.finished = true
+	r.removeFromQueue(req)
+
+	return true
+}
+
+// Must be called under lock.
+func (r *Resource) removeFromQueue(req *ResourceRequest) {
+	for i, queued := range r.queue {
+		if queued == req {
+			r.queue = append(r.queue[:i], r.queue[i+1:]...)
+			break
+		}
+	}
+
+	r.queueLength.set(r.clock.Now(), float64(len(r.queue)))
+}
+
+// Must be called under lock.
+func (r *Resource) grant() {
+	now := r.clock.Now()
+
+	for r.inUse < r.capacity && len(r.queue) > 0 {
+		req := r.queue[0]
+		r.queue[0] = nil
+		r.queue = r.queue[1:]
+
+		req.granted = true
+		r.inUse++
+		r.wait.add(now.Sub(req.requestedAt))
+
+		r.callbacks.push(req.f)
+	}
+
+	r.usage.set(now, float64(r.inUse))
+	r.queueLength.set(now, float64(len(r.queue)))
+}
+
+func (r *Resource) Capacity() int {
+	return r.capacity
+}
+
+func (r *Resource) Stats() ResourceStats {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := r.clock.Now()
+
+	return ResourceStats{
+		Wait:            r.wait,
+		Utilization:     r.usage.mean(now) / float64(r.capacity),
+		MeanQueueLength: r.queueLength.mean(now),
+		InUse:           r.inUse,
+		QueueLength:     len(r.queue),
+	}
+}
+
+// NewStore creates store of items - e.g. queue of jobs between producers and consumers.
+// Non-positive capacity means unlimited store. Puts and gets are satisfied in the order they were made.
+func NewStore[T any](c Clock, capacity int) *Store[T] {
+	return &Store[T]{
+		clock:     c,
+		callbacks: newCallbackQueue(c),
+		capacity:  capacity,
+		level:     newTimeAverage(c.Now(), 0),
+	}
+}
+
+type Store[T any] struct {
+	clock     Clock
+	callbacks callbackQueue
+	capacity  int
+
+	lock    sync.Mutex
+	items   []T
+	puts    []storePut[T]
+	gets    []storeGet[T]
+	putWait WaitStats
+	getWait WaitStats
+	level   timeAverage
+}
+
+type storePut[T any] struct {
+	item        T
+	requestedAt time.Time
+	f           func(now time.Time)
+}
+
+type storeGet[T any] struct {
+	requestedAt time.Time
+	f           func(now time.Time, item T)
+}
+
+type StoreStats struct {
+	PutWait WaitStats
+	GetWait WaitStats
+	// Time-weighted average number of items in the store.
+	MeanLevel float64
+	Level     int
+}
+
+// Put calls f once the item is placed into the store. f can be nil.
+func (s *Store[T]) Put(item T, f func(now time.Time)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.puts = append(s.puts, storePut[T]{item: item, requestedAt: s.clock.Now(), f: f})
+	s.process()
+}
+
+// Get calls f with the item once it is available.
+func (s *Store[T]) Get(f func(now time.Time, item T)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.gets = append(s.gets, storeGet[T]{requestedAt: s.clock.Now(), f: f})
+	s.process()
+}
+
+// Must be called under lock.
+func (s *Store[T]) process() {
+	now := s.clock.Now()
+
+	for {
+		switch {
+		case len(s.puts) > 0 && (s.capacity <= 0 || len(s.items) < s.capacity):
+			put := s.puts[0]
+			s.puts[0] = storePut[T]{}
+			s.puts = s.puts[1:]
+
+			s.items = append(s.items, put.item)
+			s.putWait.add(now.Sub(put.requestedAt))
+
+			if put.f != nil {
+				s.callbacks.push(put.f)
+			}
+		case len(s.gets) > 0 && len(s.items) > 0:
+			get := s.gets[0]
+			s.gets[0] = storeGet[T]{}
+			s.gets = s.gets[1:]
+
+			item := s.items[0]
+			var zero T
+			s.items[0] = zero
+			s.items = s.items[1:]
+
+			s.getWait.add(now.Sub(get.requestedAt))
+
+			s.callbacks.push(func(now time.Time) {
+				get.f(now, item)
+			})
+		default:
+			s.level.set(now, float64(len(s.items)))
+			return
+		}
+	}
+}
+
+func (s *Store[T]) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.items)
+}
+
+func (s *Store[T]) Stats() StoreStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return StoreStats{
+		PutWait:   s.putWait,
+		GetWait:   s.getWait,
+		MeanLevel: s.level.mean(s.clock.Now()),
+		Level:     len(s.items),
+	}
+}
+
+// NewContainer creates container of continuous or uncountable amount - e.g. fuel tank or budget.
+// Puts and gets are satisfied in the order they were made: a request, which can't be satisfied yet,
+// blocks the following requests of the same kind.
+func NewContainer(c Clock, capacity, initLevel float64) *Container {
+	if capacity <= 0 {
+		panic("non-positive container capacity")
+	}
+
+	if initLevel < 0 || initLevel > capacity {
+		panic("container initial level is out of range")
+	}
+
+	return &Container{
+		clock:     c,
+		callbacks: newCallbackQueue(c),
+		capacity:  capacity,
+		level:     initLevel,
+		avgLevel:  newTimeAverage(c.Now(), initLevel),
+	}
+}
+
+type Container struct {
+	clock     Clock
+	callbacks callbackQueue
+	capacity  float64
+
+	lock     sync.Mutex
+	level    float64
+	puts     []containerRequest
+	gets     []containerRequest
+	putWait  WaitStats
+	getWait  WaitStats
+	avgLevel timeAverage
+}
+
+type containerRequest struct {
+	amount      float64
+	requestedAt time.Time
+	f           func(now time.Time)
+}
+
+type ContainerStats struct {
+	PutWait WaitStats
+	GetWait WaitStats
+	// Time-weighted average level.
+	MeanLevel float64
+	// Time-weighted average part of the capacity, which was filled.
+	Utilization float64
+	Level       float64
+}
+
+// Put calls f once the amount is added to the container. f can be nil.
+func (c *Container) Put(amount float64, f func(now time.Time)) {
+	c.request(&c.puts, amount, f)
+}
+
+// Get calls f once the amount is taken from the container.
+func (c *Container) Get(amount float64, f func(now time.Time)) {
+	c.request(&c.gets, amount, f)
+}
+
+func (c *Container) request(queue *[]containerRequest, amount float64, f func(now time.Time)) {
+	if amount <= 0 || amount > c.capacity {
+		panic("container request amount is out of range")
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	*queue = append(*queue, containerRequest{amount: amount, requestedAt: c.clock.Now(), f: f})
+	c.process()
+}
+
+// Must be called under lock.
+func (c *Container) process() {
+	now := c.clock.Now()
+
+	for {
+		switch {
+		case len(c.puts) > 0 && c.level+c.puts[0].amount <= c.capacity:
+			c.level += c.puts[0].amount
+			c.satisfy(now, &c.puts, &c.putWait)
+		case len(c.gets) > 0 && c.level >= c.gets[0].amount:
+			c.level -= c.gets[0].amount
+			c.satisfy(now, &c.gets, &c.getWait)
+		default:
+			c.avgLevel.set(now, c.level)
+			return
+		}
+	}
+}
+
+// Must be called under lock.
+func (c *Container) satisfy(now time.Time, queue *[]containerRequest, wait *WaitStats) {
+	req := (*queue)[0]
+	(*queue)[0] = containerRequest{}
+	*queue = (*queue)[1:]
+
+	wait.add(now.Sub(req.requestedAt))
+
+	if req.f != nil {
+		c.callbacks.push(req.f)
+	}
+}
+
+func (c *Container) Level() float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.level
+}
+
+func (c *Container) Capacity() float64 {
+	return c.capacity
+}
+
+func (c *Container) Stats() ContainerStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	meanLevel := c.avgLevel.mean(c.clock.Now())
+
+	return ContainerStats{
+		PutWait:     c.putWait,
+		GetWait:     c.getWait,
+		MeanLevel:   meanLevel,
+		Utilization: meanLevel / c.capacity,
+		Level:       c.level,
+	}
+}
@@ -0,0 +1,177 @@
+package chrono_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResource(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	r := chrono.NewResource(s, 2)
+
+	var res []string
+
+	for i := 0; i < 4; i++ {
+		var req *chrono.ResourceRequest
+		req = r.Request(func(now time.Time) {
+			res = append(res, fmt.Sprintf("job %v started at %v", i, now.Sub(start)))
+
+			s.AfterFunc(10*time.Second, func(now time.Time) {
+				req.Release()
+			})
+		})
+	}
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []string{
+		"job 0 started at 0s",
+		"job 1 started at 0s",
+		"job 2 started at 10s",
+		"job 3 started at 10s",
+	}, res)
+
+	stats := r.Stats()
+	require.Equal(t, chrono.WaitStats{Count: 4, Total: 20 * time.Second, Max: 10 * time.Second}, stats.Wait)
+	require.Equal(t, 5*time.Second, stats.Wait.Mean())
+	require.InDelta(t, 1, stats.Utilization, 1e-9)
+	require.InDelta(t, 1, stats.MeanQueueLength, 1e-9)
+	require.Zero(t, stats.InUse)
+	require.Zero(t, stats.QueueLength)
+}
+
+func TestResourcePriority(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	r := chrono.NewResource(s, 1)
+
+	var res []string
+
+	request := func(name string, priority int) *chrono.ResourceRequest {
+		var req *chrono.ResourceRequest
+		req = r.RequestPriority(priority, func(now time.Time) {
+			res = append(res, fmt.Sprintf("%v at %v", name, now.Sub(start)))
+
+			s.AfterFunc(time.Second, func(now time.Time) {
+				req.Release()
+			})
+		})
+
+		return req
+	}
+
+	request("first", 10)
+
+	s.AfterFunc(0, func(now time.Time) {
+		request("low", 5)
+		request("high", 1)
+		request("low 2", 5)
+		cancelled := request("cancelled", 0)
+		require.True(t, cancelled.Cancel())
+		require.False(t, cancelled.Cancel())
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []string{
+		"first at 0s",
+		"high at 1s",
+		"low at 2s",
+		"low 2 at 3s",
+	}, res)
+	require.InDelta(t, 1, r.Stats().Utilization, 1e-9)
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	store := chrono.NewStore[string](s, 1)
+
+	var res []string
+
+	for _, item := range []string{"a", "b", "c"} {
+		store.Put(item, func(now time.Time) {
+			res = append(res, fmt.Sprintf("put %v at %v", item, now.Sub(start)))
+		})
+	}
+
+	var consume func()
+	consume = func() {
+		store.Get(func(now time.Time, item string) {
+			res = append(res, fmt.Sprintf("got %v at %v", item, now.Sub(start)))
+
+			if item != "c" {
+				s.AfterFunc(10*time.Second, func(now time.Time) {
+					consume()
+				})
+			}
+		})
+	}
+
+	s.AfterFunc(10*time.Second, func(now time.Time) {
+		consume()
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.ElementsMatch(t, []string{
+		"put a at 0s",
+		"got a at 10s", "put b at 10s",
+		"got b at 20s", "put c at 20s",
+		"got c at 30s",
+	}, res)
+
+	stats := store.Stats()
+	require.Equal(t, chrono.WaitStats{Count: 3, Total: 30 * time.Second, Max: 20 * time.Second}, stats.PutWait)
+	require.Equal(t, chrono.WaitStats{Count: 3}, stats.GetWait)
+	require.InDelta(t, 1, stats.MeanLevel, 1e-9)
+	require.Zero(t, stats.Level)
+}
+
+func TestContainer(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	tank := chrono.NewContainer(s, 100, 0)
+
+	var res []string
+
+	tank.Get(50, func(now time.Time) {
+		res = append(res, fmt.Sprintf("got 50 at %v", now.Sub(start)))
+	})
+
+	s.AfterFunc(10*time.Second, func(now time.Time) {
+		tank.Put(30, nil)
+	})
+
+	s.AfterFunc(20*time.Second, func(now time.Time) {
+		tank.Put(30, nil)
+	})
+
+	s.AfterFunc(30*time.Second, func(now time.Time) {})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []string{"got 50 at 20s"}, res)
+
+	stats := tank.Stats()
+	require.Equal(t, chrono.WaitStats{Count: 1, Total: 20 * time.Second, Max: 20 * time.Second}, stats.GetWait)
+	require.Equal(t, 2, stats.PutWait.Count)
+	require.InDelta(t, 10, stats.Level, 1e-9)
+	require.InDelta(t, 40.0/3, stats.MeanLevel, 1e-9)
+	require.InDelta(t, 40.0/300, stats.Utilization, 1e-9)
+	require.Panics(t, func() { tank.Put(101, nil) })
+}
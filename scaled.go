@@ -0,0 +1,397 @@
+package chrono
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NewScaledClock creates real time clock, which time runs factor times faster (or slower, if factor < 1)
+// than the wall time, starting from the specified origin.
+// All the durations are in scaled time: AfterFunc(time.Hour, ...) with factor 60 fires after one minute of wall time.
+// Handlers receive scaled time. Like RealClock, handlers are not executed concurrently, and tickers drop missed ticks.
+// Factor can be changed at any moment with SetFactor - pending timers and tickers are rescheduled accordingly.
+func NewScaledClock(origin time.Time, factor float64) *ScaledClock {
+	if factor <= 0 {
+		panic("non-positive time scale factor")
+	}
+
+	return &ScaledClock{
+		real:         NewRealClock(),
+		originScaled: origin,
+		originWall:   time.Now(),
+		factor:       factor,
+		tasks:        make(map[*scaledTask]struct{}),
+	}
+}
+
+type ScaledClock struct {
+	real *RealClock
+
+	lock         sync.RWMutex
+	originScaled time.Time
+	originWall   time.Time
+	factor       float64
+
+	tasksLock sync.Mutex
+	tasks     map[*scaledTask]struct{}
+}
+
+var _ Clock = &ScaledClock{}
+
+func (c *ScaledClock) Now() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.now()
+}
+
+// Must be called under lock.
+func (c *ScaledClock) now() time.Time {
+	return c.originScaled.Add(time.Duration(float64(time.Since(c.originWall)) * c.factor))
+}
+
+// Converts scaled time into wall time.
+func (c *ScaledClock) wallTime(t time.Time) time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.originWall.Add(time.Duration(float64(t.Sub(c.originScaled)) / c.factor))
+}
+
+func (c *ScaledClock) Factor() float64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.factor
+}
+
+// SetFactor changes the speed of the clock. Current time is preserved, and pending timers and tickers
+// are rescheduled to fire at the same scaled time.
+func (c *ScaledClock) SetFactor(factor float64) {
+	if factor <= 0 {
+		panic("non-positive time scale factor")
+	}
+
+	c.lock.Lock()
+	c.originScaled = c.now()
+	c.originWall = time.Now()
+	c.factor = factor
+	c.lock.Unlock()
+
+	c.tasksLock.Lock()
+	tasks := make([]*scaledTask, 0, len(c.tasks))
+	for task := range c.tasks {
+		tasks = append(tasks, task)
+	}
+	c.tasksLock.Unlock()
+
+	for _, task := range tasks {
+		task.rearm()
+	}
+}
+
+func (c *ScaledClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *ScaledClock) Until(t time.Time) time.Duration {
+	return t.Sub(c.Now())
+}
+
+func (c *ScaledClock) AfterFunc(d time.Duration, f func(now time.Time)) Timer {
+	return c.UntilFunc(c.Now().Add(d), f)
+}
+
+func (c *ScaledClock) UntilFunc(t time.Time, f func(now time.Time)) Timer {
+	task := c.newTask(t, 0, true, false, func(now time.Time) (time.Duration, bool) {
+		f(now)
+		return 0, false
+	})
+
+	return &scaledTimer{task}
+}
+
+func (c *ScaledClock) EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for EveryFunc")
+	}
+
+	task := c.newTask(c.Now().Add(d), d, false, true, periodicAction(d, f))
+
+	return &scaledTicker{task}
+}
+
+func (c *ScaledClock) RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	task := c.newTask(c.Now().Add(d), d, false, false, f)
+
+	return &scaledTicker{task}
+}
+
+// Close stops all pending timers and tickers and waits for running handlers. See RealClock.Close.
+func (c *ScaledClock) Close(ctx context.Context) error {
+	// Closing real clock first, so that tasks scheduled concurrently with Close fail to arm and become inactive
+	err := c.real.Close(ctx)
+
+	c.tasksLock.Lock()
+	tasks := make([]*scaledTask, 0, len(c.tasks))
+	for task := range c.tasks {
+		tasks = append(tasks, task)
+	}
+	c.tasksLock.Unlock()
+
+	for _, task := range tasks {
+		task.stop()
+	}
+
+	return err
+}
+
+// Periodic tickers drop missed ticks same as tickers of RealClock.
+func (c *ScaledClock) newTask(deadline time.Time, period time.Duration, oneShot, dropMissed bool, action func(now time.Time) (time.Duration, bool)) *scaledTask {
+	t := &scaledTask{
+		c:          c,
+		action:     action,
+		oneShot:    oneShot,
+		dropMissed: dropMissed,
+	}
+
+	t.period.Store(int64(period))
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.deadline = deadline
+	t.setActive(t.arm())
+
+	return t
+}
+
+// Timer or ticker of ScaledClock. Keeps its deadline in scaled time,
+// and is executed by timer of RealClock, which is rearmed when scale factor changes.
+type scaledTask struct {
+	c          *ScaledClock
+	action     func(now time.Time) (time.Duration, bool)
+	oneShot    bool
+	dropMissed bool
+
+	period    atomic.Int64
+	fireCount atomic.Int64
+
+	lock     sync.Mutex
+	inner    Timer
+	deadline time.Time
+	active   bool
+	firing   bool
+	// Incremented on each arming of the inner timer and on each Stop,
+	// to ignore outdated firings of the inner timer and rescheduling by the running handler.
+	generation uint64
+}
+
+// Must be called under lock.
+func (t *scaledTask) setActive(active bool) {
+	t.active = active
+
+	t.c.tasksLock.Lock()
+	defer t.c.tasksLock.Unlock()
+
+	if active {
+		t.c.tasks[t] = struct{}{}
+	} else {
+		delete(t.c.tasks, t)
+	}
+}
+
+// Must be called under lock. Replaces inner timer with the one firing at the deadline according to the current
+// scale factor. Returns false if the clock is closed.
+func (t *scaledTask) arm() bool {
+	if t.inner != nil {
+		t.inner.Stop()
+	}
+
+	t.generation++
+	generation := t.generation
+
+	inner, err := t.c.real.TryUntilFunc(t.c.wallTime(t.deadline), func(_ time.Time) {
+		t.fire(generation)
+	})
+	t.inner = inner
+
+	return err == nil
+}
+
+func (t *scaledTask) fire(generation uint64) {
+	t.lock.Lock()
+	if t.generation != generation || !t.active {
+		// Rearmed or stopped after the inner timer has fired
+		t.lock.Unlock()
+		return
+	}
+
+	deadline := t.deadline
+	t.firing = true
+	if t.oneShot {
+		t.setActive(false)
+	}
+	t.lock.Unlock()
+
+	t.fireCount.Add(1)
+
+	next, contin := t.action(t.c.Now())
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.firing = false
+
+	if t.generation != generation {
+		// Stopped or reset while running
+		return
+	}
+
+	if !contin {
+		t.setActive(false)
+		return
+	}
+
+	t.period.Store(int64(next))
+	t.deadline = deadline.Add(max(next, 0))
+
+	if t.dropMissed {
+		now := t.c.Now()
+
+		for !t.deadline.After(now) {
+			t.deadline = t.deadline.Add(next)
+		}
+	}
+
+	if !t.arm() {
+		t.setActive(false)
+	}
+}
+
+// Reschedules inner timer according to the current scale factor.
+func (t *scaledTask) rearm() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.active || t.firing {
+		// Running task will be rescheduled with new factor when it is finished
+		return
+	}
+
+	if !t.arm() {
+		t.setActive(false)
+	}
+}
+
+func (t *scaledTask) stop() (wasActive bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.cancel()
+}
+
+// Must be called under lock.
+func (t *scaledTask) cancel() (wasActive bool) {
+	wasActive = t.active
+	t.generation++
+	t.inner.Stop()
+	t.setActive(false)
+
+	return wasActive
+}
+
+func (t *scaledTask) resetAt(deadline time.Time) (wasActive bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	wasActive = t.active
+	t.deadline = deadline
+	t.setActive(t.arm())
+
+	return wasActive
+}
+
+func (t *scaledTask) getDeadline() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.active {
+		return time.Time{}
+	}
+
+	return t.deadline
+}
+
+func (t *scaledTask) isActive() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.active
+}
+
+type scaledTimer struct {
+	task *scaledTask
+}
+
+var _ Timer = &scaledTimer{}
+
+func (t *scaledTimer) Reset(d time.Duration) bool {
+	return t.task.resetAt(t.task.c.Now().Add(d))
+}
+
+func (t *scaledTimer) ResetAt(deadline time.Time) bool {
+	return t.task.resetAt(deadline)
+}
+
+func (t *scaledTimer) Stop() bool {
+	return t.task.stop()
+}
+
+func (t *scaledTimer) Deadline() time.Time {
+	return t.task.getDeadline()
+}
+
+func (t *scaledTimer) Active() bool {
+	return t.task.isActive()
+}
+
+func (t *scaledTimer) FireCount() int {
+	return int(t.task.fireCount.Load())
+}
+
+type scaledTicker struct {
+	task *scaledTask
+}
+
+var _ Ticker = &scaledTicker{}
+
+func (t *scaledTicker) Reset(d time.Duration) {
+	t.task.resetAt(t.task.c.Now().Add(d))
+}
+
+func (t *scaledTicker) ResetAt(deadline time.Time) {
+	t.task.resetAt(deadline)
+}
+
+func (t *scaledTicker) Stop() {
+	t.task.stop()
+}
+
+func (t *scaledTicker) Deadline() time.Time {
+	return t.task.getDeadline()
+}
+
+func (t *scaledTicker) Active() bool {
+	return t.task.isActive()
+}
+
+func (t *scaledTicker) FireCount() int {
+	return int(t.task.fireCount.Load())
+}
+
+func (t *scaledTicker) Period() time.Duration {
+	return time.Duration(t.task.period.Load())
+}
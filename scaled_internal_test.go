@@ -0,0 +1,58 @@
+package chrono
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Internal test, because the race between firing of the inner timer and taking the lock of the task
+// can't be reproduced reliably from outside. The lock is held by the test until the inner timer fires.
+func TestScaledTaskChangedAfterInnerTimerFired(t *testing.T) {
+	t.Parallel()
+
+	c := NewScaledClock(time.Now(), 1000)
+	defer c.Close(context.Background())
+
+	// Returns timer, which inner timer has already fired, and which is waiting for the lock held by the caller.
+	fireLocked := func(runs *atomic.Int32) *scaledTimer {
+		timer := c.AfterFunc(time.Hour, func(now time.Time) {
+			runs.Add(1)
+		}).(*scaledTimer)
+
+		timer.task.lock.Lock()
+		timer.task.deadline = c.Now()
+		timer.task.arm()
+
+		for timer.task.inner.FireCount() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		return timer
+	}
+
+	var rearmedRuns atomic.Int32
+	rearmed := fireLocked(&rearmedRuns)
+	// Same as what SetFactor does
+	rearmed.task.arm()
+	rearmed.task.lock.Unlock()
+
+	var stoppedRuns atomic.Int32
+	stopped := fireLocked(&stoppedRuns)
+	// Same as what Stop does
+	require.True(t, stopped.task.cancel())
+	stopped.task.lock.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	require.EqualValues(t, 1, rearmedRuns.Load())
+	require.Equal(t, 1, rearmed.FireCount())
+	require.False(t, rearmed.Active())
+
+	require.Zero(t, stoppedRuns.Load())
+	require.Zero(t, stopped.FireCount())
+	require.False(t, stopped.Active())
+}
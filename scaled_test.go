@@ -0,0 +1,117 @@
+package chrono_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaledClock(t *testing.T) {
+	t.Parallel()
+
+	origin := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Now()
+	c := chrono.NewScaledClock(origin, 36000) // 1 hour in 100 ms
+	defer c.Close(context.Background())
+
+	now := c.Now()
+
+	var firedAt atomic.Pointer[time.Time]
+	timer := c.AfterFunc(time.Hour, func(now time.Time) {
+		firedAt.Store(&now)
+	})
+
+	// Bounded by the wall time passed since the creation, so that slow test machine does not fail the test
+	scaledElapsed := time.Duration(float64(time.Since(created)) * 36000)
+	require.False(t, now.Before(origin))
+	require.False(t, now.After(origin.Add(scaledElapsed)))
+	require.False(t, timer.Deadline().Before(origin.Add(time.Hour)))
+	require.False(t, timer.Deadline().After(origin.Add(time.Hour+scaledElapsed)))
+
+	var ticks atomic.Int32
+	ticker := c.EveryFunc(10*time.Minute, func(now time.Time) bool {
+		ticks.Add(1)
+		return true
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	require.Nil(t, firedAt.Load())
+	require.InDelta(t, 2, ticks.Load(), 1)
+
+	// Slowing down 10 times: remaining half an hour now takes 500 ms
+	c.SetFactor(3600)
+	require.Equal(t, float64(3600), c.Factor())
+
+	time.Sleep(150 * time.Millisecond)
+	require.Nil(t, firedAt.Load())
+	require.True(t, timer.Active())
+
+	deadline := time.Now().Add(time.Second)
+	for firedAt.Load() == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.NotNil(t, firedAt.Load())
+	require.WithinDuration(t, origin.Add(time.Hour), *firedAt.Load(), 5*time.Minute)
+	require.False(t, timer.Active())
+	require.Equal(t, 1, timer.FireCount())
+
+	ticker.Stop()
+	require.False(t, ticker.Active())
+	require.Equal(t, 10*time.Minute, ticker.Period())
+	require.InDelta(t, 6, ticks.Load(), 1)
+}
+
+func TestScaledClockDropsMissedTicks(t *testing.T) {
+	t.Parallel()
+
+	c := chrono.NewScaledClock(time.Now(), 1)
+	defer c.Close(context.Background())
+
+	const period = 10 * time.Millisecond
+
+	var slowTickEnd time.Time
+	results := make(chan []time.Time, 1)
+	var ticks []time.Time
+
+	c.EveryFunc(period, func(now time.Time) bool {
+		ticks = append(ticks, now)
+
+		switch len(ticks) {
+		case 1:
+			time.Sleep(5 * period)
+			slowTickEnd = c.Now()
+		case 3:
+			results <- ticks
+			return false
+		}
+
+		return true
+	})
+
+	got := <-results
+
+	// Ticks missed during the slow handler are dropped instead of being executed all at once
+	require.False(t, got[2].Before(slowTickEnd.Add(period)))
+}
+
+func TestScaledClockClosed(t *testing.T) {
+	t.Parallel()
+
+	c := chrono.NewScaledClock(time.Now(), 1)
+	require.NoError(t, c.Close(context.Background()))
+
+	timer := c.AfterFunc(time.Millisecond, func(now time.Time) {})
+	require.False(t, timer.Active())
+	require.True(t, timer.Deadline().IsZero())
+
+	ticker := c.EveryFunc(time.Millisecond, func(now time.Time) bool { return true })
+	require.False(t, ticker.Active())
+
+	require.False(t, timer.Reset(time.Millisecond))
+	require.False(t, timer.Active())
+}
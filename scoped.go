@@ -0,0 +1,215 @@
+package chrono
+
+import (
+	"sync"
+	"time"
+)
+
+// NewScopedClock creates clock, which tracks all the timers and tickers created through it,
+// so they all can be stopped at once with StopAll - e.g. when a session or a component is finished.
+// Fired timers and finished tickers are automatically forgotten.
+// Child scopes can be created with NewChild - stopping parent scope stops children too.
+func NewScopedClock(c Clock) *ScopedClock {
+	return &ScopedClock{
+		Clock:    c,
+		tasks:    make(map[scopedTask]struct{}),
+		children: make(map[*ScopedClock]struct{}),
+	}
+}
+
+type ScopedClock struct {
+	Clock
+
+	parent *ScopedClock
+
+	lock     sync.Mutex
+	tasks    map[scopedTask]struct{}
+	children map[*ScopedClock]struct{}
+}
+
+var _ Clock = &ScopedClock{}
+
+type scopedTask interface {
+	stopInScope()
+}
+
+// NewChild creates nested scope. Its timers and tickers are stopped when parent scope is stopped.
+func (c *ScopedClock) NewChild() *ScopedClock {
+	child := NewScopedClock(c.Clock)
+	child.parent = c
+
+	c.lock.Lock()
+	c.children[child] = struct{}{}
+	c.lock.Unlock()
+
+	return child
+}
+
+// StopAll stops all the timers and tickers created in this scope and in its child scopes.
+// Scope can still be used after that.
+func (c *ScopedClock) StopAll() {
+	c.lock.Lock()
+	tasks := c.tasks
+	c.tasks = make(map[scopedTask]struct{})
+
+	// Children map is modified by NewChild and Close of children, so it is not iterated without lock
+	children := make([]*ScopedClock, 0, len(c.children))
+	for child := range c.children {
+		children = append(children, child)
+	}
+	c.lock.Unlock()
+
+	for task := range tasks {
+		task.stopInScope()
+	}
+
+	for _, child := range children {
+		child.StopAll()
+	}
+}
+
+// Close stops all the timers and tickers of the scope, and detaches it from its parent.
+func (c *ScopedClock) Close() {
+	c.StopAll()
+
+	if c.parent != nil {
+		c.parent.lock.Lock()
+		delete(c.parent.children, c)
+		c.parent.lock.Unlock()
+	}
+}
+
+// Returns the number of timers and tickers, which are tracked by the scope (not including child scopes).
+func (c *ScopedClock) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.tasks)
+}
+
+func (c *ScopedClock) track(t scopedTask) {
+	c.lock.Lock()
+	c.tasks[t] = struct{}{}
+	c.lock.Unlock()
+}
+
+// Schedules timer or ticker and starts tracking it. Lock is held while scheduling,
+// so the task can not be stopped by StopAll or forgotten by its handler before it is created.
+func (c *ScopedClock) trackNew(t scopedTask, schedule func()) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	schedule()
+	c.tasks[t] = struct{}{}
+}
+
+func (c *ScopedClock) forget(t scopedTask) {
+	c.lock.Lock()
+	delete(c.tasks, t)
+	c.lock.Unlock()
+}
+
+func (c *ScopedClock) AfterFunc(d time.Duration, f func(now time.Time)) Timer {
+	t := &scopedTimer{scope: c}
+	c.trackNew(t, func() {
+		t.Timer = c.Clock.AfterFunc(d, t.wrap(f))
+	})
+
+	return t
+}
+
+func (c *ScopedClock) UntilFunc(deadline time.Time, f func(now time.Time)) Timer {
+	t := &scopedTimer{scope: c}
+	c.trackNew(t, func() {
+		t.Timer = c.Clock.UntilFunc(deadline, t.wrap(f))
+	})
+
+	return t
+}
+
+func (c *ScopedClock) EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker {
+	t := &scopedTicker{scope: c}
+	c.trackNew(t, func() {
+		t.Ticker = c.Clock.EveryFunc(d, func(now time.Time) bool {
+			if !f(now) {
+				c.forget(t)
+				return false
+			}
+
+			return true
+		})
+	})
+
+	return t
+}
+
+func (c *ScopedClock) RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	t := &scopedTicker{scope: c}
+	c.trackNew(t, func() {
+		t.Ticker = RepeatFunc(c.Clock, d, func(now time.Time) (time.Duration, bool) {
+			next, contin := f(now)
+			if !contin {
+				c.forget(t)
+			}
+
+			return next, contin
+		})
+	})
+
+	return t
+}
+
+type scopedTimer struct {
+	Timer
+	scope *ScopedClock
+}
+
+func (t *scopedTimer) wrap(f func(now time.Time)) func(now time.Time) {
+	return func(now time.Time) {
+		t.scope.forget(t)
+		f(now)
+	}
+}
+
+func (t *scopedTimer) Stop() bool {
+	t.scope.forget(t)
+	return t.Timer.Stop()
+}
+
+func (t *scopedTimer) Reset(d time.Duration) bool {
+	t.scope.track(t)
+	return t.Timer.Reset(d)
+}
+
+func (t *scopedTimer) ResetAt(deadline time.Time) bool {
+	t.scope.track(t)
+	return t.Timer.ResetAt(deadline)
+}
+
+func (t *scopedTimer) stopInScope() {
+	t.Timer.Stop()
+}
+
+type scopedTicker struct {
+	Ticker
+	scope *ScopedClock
+}
+
+func (t *scopedTicker) Stop() {
+	t.scope.forget(t)
+	t.Ticker.Stop()
+}
+
+func (t *scopedTicker) Reset(d time.Duration) {
+	t.scope.track(t)
+	t.Ticker.Reset(d)
+}
+
+func (t *scopedTicker) ResetAt(deadline time.Time) {
+	t.scope.track(t)
+	t.Ticker.ResetAt(deadline)
+}
+
+func (t *scopedTicker) stopInScope() {
+	t.Ticker.Stop()
+}
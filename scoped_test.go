@@ -0,0 +1,101 @@
+package chrono_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopedClock(t *testing.T) {
+	t.Parallel()
+
+	s := chrono.NewSimulator(time.Now())
+	scope := chrono.NewScopedClock(s)
+	child := scope.NewChild()
+
+	var res []string
+
+	scope.AfterFunc(time.Minute, func(now time.Time) {
+		res = append(res, "fired")
+	})
+
+	scope.AfterFunc(3*time.Minute, func(now time.Time) {
+		res = append(res, "stopped timer")
+	})
+
+	scope.EveryFunc(time.Minute, func(now time.Time) bool {
+		res = append(res, "tick")
+		return true
+	})
+
+	child.EveryFunc(time.Minute, func(now time.Time) bool {
+		res = append(res, "child tick")
+		return true
+	})
+
+	child.RepeatFunc(time.Minute, func(now time.Time) (time.Duration, bool) {
+		res = append(res, "child repeat")
+		return time.Minute, false
+	})
+
+	require.Equal(t, 3, scope.Len())
+	require.Equal(t, 2, child.Len())
+
+	s.AfterFunc(90*time.Second, func(now time.Time) {
+		require.Equal(t, 2, scope.Len())
+		require.Equal(t, 1, child.Len())
+
+		scope.StopAll()
+
+		require.Equal(t, 0, scope.Len())
+		require.Equal(t, 0, child.Len())
+	})
+
+	_, err := s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"fired", "tick", "child tick", "child repeat"}, res)
+
+	res = nil
+	timer := child.AfterFunc(time.Minute, func(now time.Time) {
+		res = append(res, "after reset")
+	})
+	require.Equal(t, 1, child.Len())
+	require.True(t, timer.Stop())
+	require.Equal(t, 0, child.Len())
+	timer.Reset(time.Minute)
+	require.Equal(t, 1, child.Len())
+
+	child.Close()
+	child.AfterFunc(time.Minute, func(now time.Time) {
+		res = append(res, "detached child")
+	})
+	scope.StopAll()
+
+	_, err = s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"detached child"}, res)
+}
+
+func TestScopedClockConcurrentChildren(t *testing.T) {
+	t.Parallel()
+
+	scope := chrono.NewScopedClock(chrono.NewSimulator(time.Now()))
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 1000; i++ {
+			scope.NewChild().Close()
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		scope.StopAll()
+	}
+
+	<-done
+}
@@ -0,0 +1,539 @@
+package chrono
+
+import (
+	"sync"
+	"time"
+)
+
+// Callback-based synchronization primitives, which can be used in simulation without blocking goroutines.
+// Their callbacks are always executed as tasks of the clock (never inline). Callbacks of each primitive
+// are executed in the order they become ready, so they behave the same way with Simulator and with RealClock.
+// Methods are safe for concurrent use.
+
+// Queue of callbacks, which are executed as tasks of the clock in the order they were added.
+// Clocks may not keep the order of tasks with equal deadlines, so the queue is drained by one task at a time.
+type callbackQueue struct {
+	clock Clock
+
+	lock      sync.Mutex
+	queue     []func(now time.Time)
+	scheduled bool
+}
+
+func newCallbackQueue(c Clock) callbackQueue {
+	return callbackQueue{clock: c}
+}
+
+func (q *callbackQueue) push(f func(now time.Time)) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	q.queue = append(q.queue, f)
+
+	if !q.scheduled {
+		q.scheduled = true
+		q.clock.AfterFunc(0, q.drain)
+	}
+}
+
+// Executes callbacks, which are queued by now. Callbacks queued by them are executed by the next task,
+// so that each task of the clock does a limited amount of work.
+func (q *callbackQueue) drain(now time.Time) {
+	q.lock.Lock()
+	batch := q.queue
+	q.queue = nil
+	q.lock.Unlock()
+
+	for _, f := range batch {
+		f(now)
+	}
+
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	if len(q.queue) == 0 {
+		q.scheduled = false
+		return
+	}
+
+	q.clock.AfterFunc(0, q.drain)
+}
+
+// NewSimMutex creates mutex, which grants the lock to the waiters in the order they requested it.
+func NewSimMutex(c Clock) *SimMutex {
+	return &SimMutex{callbacks: newCallbackQueue(c)}
+}
+
+type SimMutex struct {
+	callbacks callbackQueue
+
+	lock    sync.Mutex
+	locked  bool
+	waiters []func(now time.Time)
+}
+
+// LockFunc calls f once the lock is acquired. The lock is held until Unlock is called.
+func (m *SimMutex) LockFunc(f func(now time.Time)) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.locked {
+		m.waiters = append(m.waiters, f)
+		return
+	}
+
+	m.locked = true
+	m.callbacks.push(f)
+}
+
+// TryLock acquires the lock if it is free.
+func (m *SimMutex) TryLock() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.locked {
+		return false
+	}
+
+	m.locked = true
+
+	return true
+}
+
+// Unlock passes the lock to the next waiter, if there is any.
+func (m *SimMutex) Unlock() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if !m.locked {
+		panic("unlock of unlocked SimMutex")
+	}
+
+	if len(m.waiters) == 0 {
+		m.locked = false
+		return
+	}
+
+	next := m.waiters[0]
+	m.waiters[0] = nil
+	m.waiters = m.waiters[1:]
+
+	m.callbacks.push(next)
+}
+
+func (m *SimMutex) Locked() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.locked
+}
+
+// Returns the number of callbacks waiting for the lock.
+func (m *SimMutex) Waiting() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return len(m.waiters)
+}
+
+// NewSimCond creates condition variable. If mutex is not nil, it must be held when Wait is called -
+// Wait releases it, and the callback is executed after the mutex is acquired again, same as with sync.Cond.
+func NewSimCond(c Clock, l *SimMutex) *SimCond {
+	return &SimCond{
+		clock:     c,
+		callbacks: newCallbackQueue(c),
+		L:         l,
+	}
+}
+
+type SimCond struct {
+	L *SimMutex
+
+	clock     Clock
+	callbacks callbackQueue
+
+	lock    sync.Mutex
+	waiters []*condWaiter
+}
+
+type condWaiter struct {
+	f     func(now time.Time, signaled bool)
+	timer Timer
+	done  bool
+}
+
+// Wait calls f after Signal or Broadcast.
+func (c *SimCond) Wait(f func(now time.Time)) {
+	c.wait(&condWaiter{
+		f: func(now time.Time, _ bool) {
+			f(now)
+		},
+	})
+}
+
+// WaitTimeout calls f after Signal or Broadcast, or when timeout expires. In the last case signaled is false.
+func (c *SimCond) WaitTimeout(timeout time.Duration, f func(now time.Time, signaled bool)) {
+	w := &condWaiter{f: f}
+
+	c.lock.Lock()
+	w.timer = c.clock.AfterFunc(timeout, func(now time.Time) {
+		c.lock.Lock()
+		if w.done {
+			c.lock.Unlock()
+			return
+		}
+
+		w.done = true
+		c.removeWaiter(w)
+		c.lock.Unlock()
+
+		c.resume(w, false)
+	})
+	c.lock.Unlock()
+
+	c.wait(w)
+}
+
+func (c *SimCond) wait(w *condWaiter) {
+	c.lock.Lock()
+	if !w.done {
+		c.waiters = append(c.waiters, w)
+	}
+	c.lock.Unlock()
+
+	if c.L != nil {
+		c.L.Unlock()
+	}
+}
+
+// Signal wakes up the longest waiting callback.
+func (c *SimCond) Signal() {
+	c.lock.Lock()
+
+	if len(c.waiters) == 0 {
+		c.lock.Unlock()
+		return
+	}
+
+	w := c.waiters[0]
+	c.waiters[0] = nil
+	c.waiters = c.waiters[1:]
+	c.finishWaiting(w)
+
+	c.lock.Unlock()
+
+	c.resume(w, true)
+}
+
+// Broadcast wakes up all the waiting callbacks.
+func (c *SimCond) Broadcast() {
+	c.lock.Lock()
+
+	waiters := c.waiters
+	c.waiters = nil
+
+	for _, w := range waiters {
+		c.finishWaiting(w)
+	}
+
+	c.lock.Unlock()
+
+	for _, w := range waiters {
+		c.resume(w, true)
+	}
+}
+
+// Must be called under lock.
+func (c *SimCond) finishWaiting(w *condWaiter) {
+	w.done = true
+
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+// Must be called under lock.
+func (c *SimCond) removeWaiter(w *condWaiter) {
+	for i, waiter := range c.waiters {
+		if waiter == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *SimCond) resume(w *condWaiter, signaled bool) {
+	f := func(now time.Time) {
+		w.f(now, signaled)
+	}
+
+	if c.L != nil {
+		c.L.LockFunc(f)
+		return
+	}
+
+	c.callbacks.push(f)
+}
+
+// NewSimWaitGroup creates wait group, which notifies callbacks when its counter becomes zero.
+func NewSimWaitGroup(c Clock) *SimWaitGroup {
+	return &SimWaitGroup{callbacks: newCallbackQueue(c)}
+}
+
+type SimWaitGroup struct {
+	callbacks callbackQueue
+
+	lock    sync.Mutex
+	count   int
+	waiters []func(now time.Time)
+}
+
+func (wg *SimWaitGroup) Add(delta int) {
+	wg.lock.Lock()
+	defer wg.lock.Unlock()
+
+	wg.count += delta
+
+	if wg.count < 0 {
+		panic("negative SimWaitGroup counter")
+	}
+
+	if wg.count > 0 {
+		return
+	}
+
+	for _, f := range wg.waiters {
+		wg.callbacks.push(f)
+	}
+
+	wg.waiters = nil
+}
+
+func (wg *SimWaitGroup) Done() {
+	wg.Add(-1)
+}
+
+// OnDone calls f once the counter becomes zero. If it is already zero, f is called right away.
+func (wg *SimWaitGroup) OnDone(f func(now time.Time)) {
+	wg.lock.Lock()
+	defer wg.lock.Unlock()
+
+	if wg.count == 0 {
+		wg.callbacks.push(f)
+		return
+	}
+
+	wg.waiters = append(wg.waiters, f)
+}
+
+func (wg *SimWaitGroup) Count() int {
+	wg.lock.Lock()
+	defer wg.lock.Unlock()
+
+	return wg.count
+}
+
+// NewSimChan creates channel with specified capacity. Zero capacity means unbuffered channel -
+// sending completes only when the value is taken by a receiver.
+// Unlike Go channels, sending to closed channel does not panic - the send callback receives ok = false.
+func NewSimChan[T any](c Clock, capacity int) *SimChan[T] {
+	if capacity < 0 {
+		panic("negative SimChan capacity")
+	}
+
+	return &SimChan[T]{
+		callbacks: newCallbackQueue(c),
+		capacity:  capacity,
+	}
+}
+
+type SimChan[T any] struct {
+	callbacks callbackQueue
+	capacity  int
+
+	lock      sync.Mutex
+	buffer    []T
+	senders   []chanSender[T]
+	receivers []func(now time.Time, v T, ok bool)
+	closed    bool
+}
+
+type chanSender[T any] struct {
+	v T
+	f func(now time.Time, ok bool)
+}
+
+// SendFunc sends the value and calls f once it is accepted by the channel, or once the channel is closed.
+// f can be nil.
+func (ch *SimChan[T]) SendFunc(v T, f func(now time.Time, ok bool)) {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	if ch.closed {
+		ch.notifySender(f, false)
+		return
+	}
+
+	if len(ch.receivers) > 0 {
+		ch.notifySender(f, true)
+		ch.notifyReceiver(ch.popReceiver(), v, true)
+
+		return
+	}
+
+	if len(ch.buffer) < ch.capacity {
+		ch.buffer = append(ch.buffer, v)
+		ch.notifySender(f, true)
+
+		return
+	}
+
+	ch.senders = append(ch.senders, chanSender[T]{v: v, f: f})
+}
+
+// TrySend sends the value if it can be accepted right away. Returns false if the channel is closed.
+func (ch *SimChan[T]) TrySend(v T) bool {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	switch {
+	case ch.closed:
+		return false
+	case len(ch.receivers) > 0:
+		ch.notifyReceiver(ch.popReceiver(), v, true)
+		return true
+	case len(ch.buffer) < ch.capacity:
+		ch.buffer = append(ch.buffer, v)
+		return true
+	default:
+		return false
+	}
+}
+
+// RecvFunc calls f with the received value. ok is false if the channel is closed and there are no more values.
+func (ch *SimChan[T]) RecvFunc(f func(now time.Time, v T, ok bool)) {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	v, ok, received := ch.tryRecv()
+	if !received {
+		ch.receivers = append(ch.receivers, f)
+		return
+	}
+
+	ch.notifyReceiver(f, v, ok)
+}
+
+// TryRecv receives the value if it is available right away.
+// Returns false if there is no value, or if the channel is closed.
+func (ch *SimChan[T]) TryRecv() (T, bool) {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	v, ok, _ := ch.tryRecv()
+
+	return v, ok
+}
+
+// Close makes waiting receivers to get ok = false after remaining values are received.
+// Waiting senders get ok = false.
+func (ch *SimChan[T]) Close() {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	if ch.closed {
+		panic("close of closed SimChan")
+	}
+
+	ch.closed = true
+
+	for _, s := range ch.senders {
+		ch.notifySender(s.f, false)
+	}
+
+	ch.senders = nil
+
+	// Receivers wait only if there are no values in the buffer
+	var zero T
+	for _, f := range ch.receivers {
+		ch.notifyReceiver(f, zero, false)
+	}
+
+	ch.receivers = nil
+}
+
+// Returns the number of values in the buffer.
+func (ch *SimChan[T]) Len() int {
+	ch.lock.Lock()
+	defer ch.lock.Unlock()
+
+	return len(ch.buffer)
+}
+
+func (ch *SimChan[T]) Cap() int {
+	return ch.capacity
+}
+
+// Must be called under lock. Returns false if there is nothing to receive yet.
+func (ch *SimChan[T]) tryRecv() (v T, ok, received bool) {
+	if len(ch.buffer) > 0 {
+		v = ch.buffer[0]
+		ch.buffer = ch.buffer[1:]
+
+		// Freed space in the buffer is taken by the next waiting sender
+		if len(ch.senders) > 0 {
+			s := ch.popSender()
+			ch.buffer = append(ch.buffer, s.v)
+			ch.notifySender(s.f, true)
+		}
+
+		return v, true, true
+	}
+
+	if len(ch.senders) > 0 {
+		s := ch.popSender()
+		ch.notifySender(s.f, true)
+
+		return s.v, true, true
+	}
+
+	if ch.closed {
+		return v, false, true
+	}
+
+	return v, false, false
+}
+
+// Must be called under lock.
+func (ch *SimChan[T]) popSender() chanSender[T] {
+	s := ch.senders[0]
+	ch.senders[0] = chanSender[T]{}
+	ch.senders = ch.senders[1:]
+
+	return s
+}
+
+// Must be called under lock.
+func (ch *SimChan[T]) popReceiver() func(now time.Time, v T, ok bool) {
+	f := ch.receivers[0]
+	ch.receivers[0] = nil
+	ch.receivers = ch.receivers[1:]
+
+	return f
+}
+
+func (ch *SimChan[T]) notifySender(f func(now time.Time, ok bool), ok bool) {
+	if f == nil {
+		return
+	}
+
+	ch.callbacks.push(func(now time.Time) {
+		f(now, ok)
+	})
+}
+
+func (ch *SimChan[T]) notifyReceiver(f func(now time.Time, v T, ok bool), v T, ok bool) {
+	ch.callbacks.push(func(now time.Time) {
+		f(now, v, ok)
+	})
+}
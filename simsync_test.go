@@ -0,0 +1,235 @@
+package chrono_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimMutex(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	m := chrono.NewSimMutex(s)
+
+	var res []string
+
+	for i := 0; i < 3; i++ {
+		m.LockFunc(func(now time.Time) {
+			res = append(res, fmt.Sprintf("%v locked at %v", i, now.Sub(start)))
+
+			s.AfterFunc(time.Second, func(now time.Time) {
+				m.Unlock()
+			})
+		})
+	}
+
+	require.True(t, m.Locked())
+	require.Equal(t, 2, m.Waiting())
+	require.False(t, m.TryLock())
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []string{
+		"0 locked at 0s",
+		"1 locked at 1s",
+		"2 locked at 2s",
+	}, res)
+	require.False(t, m.Locked())
+	require.True(t, m.TryLock())
+}
+
+// Simultaneous callbacks are executed in the order they become ready, even though simulator
+// does not keep the order of tasks with equal deadlines.
+func TestSimWaitGroupCallbacksOrder(t *testing.T) {
+	t.Parallel()
+
+	s := chrono.NewSimulator(time.Now())
+	wg := chrono.NewSimWaitGroup(s)
+	wg.Add(1)
+
+	var res, expected []int
+
+	for i := 0; i < 100; i++ {
+		expected = append(expected, i)
+		wg.OnDone(func(now time.Time) {
+			res = append(res, i)
+		})
+	}
+
+	s.AfterFunc(time.Second, func(now time.Time) {
+		wg.Done()
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, expected, res)
+}
+
+func TestSimCond(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	m := chrono.NewSimMutex(s)
+	cond := chrono.NewSimCond(s, m)
+
+	var res []string
+	ready := false
+
+	m.LockFunc(func(now time.Time) {
+		cond.WaitTimeout(time.Second, func(now time.Time, signaled bool) {
+			require.True(t, m.Locked())
+			res = append(res, fmt.Sprintf("short waiter: signaled=%v at %v", signaled, now.Sub(start)))
+			m.Unlock()
+		})
+	})
+
+	m.LockFunc(func(now time.Time) {
+		cond.WaitTimeout(time.Minute, func(now time.Time, signaled bool) {
+			res = append(res, fmt.Sprintf("long waiter: signaled=%v ready=%v at %v", signaled, ready, now.Sub(start)))
+			m.Unlock()
+		})
+	})
+
+	s.AfterFunc(2*time.Second, func(now time.Time) {
+		m.LockFunc(func(now time.Time) {
+			ready = true
+			cond.Broadcast()
+			m.Unlock()
+		})
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []string{
+		"short waiter: signaled=false at 1s",
+		"long waiter: signaled=true ready=true at 2s",
+	}, res)
+	require.Equal(t, start.Add(2*time.Second), s.Now())
+}
+
+func TestSimWaitGroup(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	wg := chrono.NewSimWaitGroup(s)
+
+	var doneAt []time.Duration
+
+	wg.Add(3)
+	for i := 1; i <= 3; i++ {
+		s.AfterFunc(time.Duration(i)*time.Second, func(now time.Time) {
+			wg.Done()
+		})
+	}
+
+	wg.OnDone(func(now time.Time) {
+		doneAt = append(doneAt, now.Sub(start))
+
+		wg.OnDone(func(now time.Time) {
+			doneAt = append(doneAt, now.Sub(start))
+		})
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []time.Duration{3 * time.Second, 3 * time.Second}, doneAt)
+	require.Panics(t, wg.Done)
+}
+
+// Producer is faster than consumer, so it is slowed down by the capacity of the channel.
+func runSimChanPipeline(c chrono.Clock, capacity int, step time.Duration, onFinish func()) *[]string {
+	ch := chrono.NewSimChan[int](c, capacity)
+	var res []string
+	var consumerStep int
+
+	// Events are recorded with the step of consumer they happened at
+	record := func(now time.Time, event string) {
+		res = append(res, fmt.Sprintf("%v: %v", consumerStep, event))
+	}
+
+	var produce func(i int)
+	produce = func(i int) {
+		if i == 5 {
+			ch.Close()
+			return
+		}
+
+		ch.SendFunc(i, func(now time.Time, ok bool) {
+			record(now, fmt.Sprintf("sent %v", i))
+			produce(i + 1)
+		})
+	}
+
+	var consume func()
+	consume = func() {
+		ch.RecvFunc(func(now time.Time, v int, ok bool) {
+			if !ok {
+				record(now, "closed")
+				onFinish()
+
+				return
+			}
+
+			record(now, fmt.Sprintf("received %v", v))
+			c.AfterFunc(step, func(now time.Time) {
+				consumerStep++
+				consume()
+			})
+		})
+	}
+
+	c.AfterFunc(0, func(now time.Time) {
+		produce(0)
+		consume()
+	})
+
+	return &res
+}
+
+func TestSimChan(t *testing.T) {
+	t.Parallel()
+
+	expected := []string{
+		"0: sent 0", "0: received 0", "0: sent 1",
+		"1: sent 2", "1: received 1",
+		"2: sent 3", "2: received 2",
+		"3: sent 4", "3: received 3",
+		"4: received 4",
+		"5: closed",
+	}
+
+	s := chrono.NewSimulator(time.Now())
+	simRes := runSimChanPipeline(s, 1, time.Second, func() {})
+	s.ProcessAll(context.Background())
+	require.Equal(t, expected, *simRes)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	realClock := chrono.NewRealClock()
+	realRes := runSimChanPipeline(realClock, 1, 10*time.Millisecond, wg.Done)
+	wg.Wait()
+	require.NoError(t, realClock.Close(context.Background()))
+	require.Equal(t, expected, *realRes)
+
+	ch := chrono.NewSimChan[int](s, 1)
+	require.True(t, ch.TrySend(1))
+	require.False(t, ch.TrySend(2))
+	require.Equal(t, 1, ch.Len())
+
+	v, ok := ch.TryRecv()
+	require.True(t, ok)
+	require.Equal(t, 1, v)
+
+	_, ok = ch.TryRecv()
+	require.False(t, ok)
+}
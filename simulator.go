@@ -11,6 +11,17 @@ import (
 // Then, the time can be advanced using Advance or ProcessAll methods.
 // Tasks are ran in their chronological order. They can generate additional tasks.
 // Simulation happens in a single thread, but tasks can be scheduled from different threads.
+//
+// Locking model:
+//   - Task queue and current time are protected by the usage lock. Every public method takes it
+//     only for the duration of the method and never calls user code while holding it.
+//   - Tasks are run without holding the usage lock, so they can freely use the simulator.
+//   - Now, Since, Until, AfterFunc, UntilFunc, EveryFunc, SetNow and Stop/Reset of timers and tickers
+//     are safe to call from any goroutine at any time, including from the running task itself.
+//     But calling them from other goroutines while processing makes the result depend on goroutines scheduling.
+//   - Processing methods (Advance*, Approach, ProcessAll*) must not be called concurrently with each other.
+//   - Stopping a task while it is running prevents it from being rescheduled (e.g. ticker stops).
+//     Resetting a task while it is running schedules it anew, and the rescheduling by the task itself is ignored.
 func NewSimulator(now time.Time) *Simulator {
 	return NewSimulatorWithOpts(now, nil)
 }
@@ -18,7 +29,7 @@ func NewSimulator(now time.Time) *Simulator {
 // Usage lock is lock used to push and pop tasks to/from the task queue,
 // and also to check current time. If nil - it is regulat sync.RWMutex.
 // Pass NoLock to disable locking if you are sure that all calls are made from the same goroutine.
-// There is no simulator lock for the sake of simplicity.
+// There is no simulator lock for the sake of simplicity, so processing methods must be called from single goroutine.
 func NewSimulatorWithOpts(now time.Time, usageLock RWLocker) *Simulator {
 	if usageLock == nil {
 		usageLock = &sync.RWMutex{}
@@ -28,6 +39,7 @@ func NewSimulatorWithOpts(now time.Time, usageLock RWLocker) *Simulator {
 		now:       now,
 		taskQueue: newTaskQueue(),
 		usageLock: usageLock,
+		wakeUp:    make(chan struct{}, 1),
 	}
 }
 
@@ -35,6 +47,21 @@ type Simulator struct {
 	usageLock RWLocker
 	now       time.Time
 	taskQueue *taskQueue
+
+	holdsLock sync.Mutex
+	holds     int
+	wakeUp    chan struct{}
+
+	inboxesLock sync.Mutex
+	inboxes     []*Inbox
+
+	strict strictModeState
+
+	limitsLock sync.Mutex
+	limits     SimulatorLimits
+
+	processingDoneLock  sync.Mutex
+	processingDoneHooks []*func(now time.Time)
 }
 
 var _ Clock = &Simulator{}
@@ -46,9 +73,11 @@ func (s *Simulator) Now() time.Time {
 	return s.now
 }
 
+// Moves the current time forward to the specified moment without running any tasks.
+// Time is never moved backward. Returns the new current time and the requested leap.
 func (s *Simulator) SetNow(now time.Time) (time.Time, time.Duration) {
-	s.usageLock.RLock()
-	defer s.usageLock.RUnlock()
+	s.usageLock.Lock()
+	defer s.usageLock.Unlock()
 
 	return s.setNow(now)
 }
@@ -86,24 +115,39 @@ func (s *Simulator) Advance() (newNow time.Time, leap time.Duration, hadTasks bo
 // Advances the current time to the next task deadline and runs the task if it is before the specified time.
 // If there are no tasks or its deadline comes not specified time, the current time is NOT changed.
 func (s *Simulator) AdvanceIfBefore(before time.Time) (newNow time.Time, leap time.Duration, hadExpiredTasks bool) {
+	newNow, leap, hadExpiredTasks, _ = s.advanceIfBefore(before, nil)
+	return newNow, leap, hadExpiredTasks
+}
+
+// Same as AdvanceIfBefore, but allows to check the next task before running it.
+// If check returns an error, the task is not run and the current time is NOT changed.
+func (s *Simulator) advanceIfBefore(before time.Time, check func(nextTask *Task) error) (newNow time.Time, leap time.Duration, hadExpiredTasks bool, err error) {
 	s.usageLock.Lock()
 
+	now := s.now
+
 	if !s.taskQueue.HasTasks() {
 		s.usageLock.Unlock()
-		return s.now, 0, false
+		return now, 0, false, nil
+	}
+
+	nextTask := s.taskQueue.PeekTask()
+
+	if !before.IsZero() && !nextTask.Deadline.Before(before) {
+		s.usageLock.Unlock()
+		return now, 0, false, nil
 	}
 
-	if !before.IsZero() {
-		nextTask := s.taskQueue.PeekTask()
-		if !nextTask.Deadline.Before(before) {
+	if check != nil {
+		if err := check(nextTask); err != nil {
 			s.usageLock.Unlock()
-			return s.now, 0, false
+			return now, 0, false, err
 		}
 	}
 
-	newNow, leap = s.processNextTask(false)
+	newNow, leap = s.processNextTask()
 
-	return newNow, leap, true
+	return newNow, leap, true, nil
 }
 
 // Processes all tasks.
@@ -113,22 +157,186 @@ func (s *Simulator) ProcessAll(ctx context.Context) (int, error) {
 }
 
 // Processes all tasks, which are set to fire before the specified time (not including).
+// While there are outstanding holds (see Hold), only tasks of the current moment are processed,
+// and instead of finishing on empty queue the method waits for new tasks or for holds to be released.
+// Events from inboxes (see NewInbox) are applied before each task and are counted as processed tasks.
+// If any of the limits (see SetLimits) is exceeded, the method stops before running the offending task
+// and returns LimitExceededError.
 func (s *Simulator) ProcessAllUntil(ctx context.Context, until time.Time) (int, error) {
+	defer s.runProcessingDoneHooks()
+
+	return s.processAllUntil(ctx, until)
+}
+
+func (s *Simulator) processAllUntil(ctx context.Context, until time.Time) (int, error) {
+	defer s.beginStrictProcessing()()
+
 	tasksProcessed := 0
+	limits := s.newLimitsChecker()
 
 	for ctx.Err() == nil {
-		_, _, hadExpiredTasks := s.AdvanceIfBefore(until)
+		if err := s.takeStrictModeViolation(); err != nil {
+			return tasksProcessed, err
+		}
+
+		tasksProcessed += s.drainInboxes()
 
-		if !hadExpiredTasks {
-			return tasksProcessed, nil
+		if !s.isHeld() {
+			_, _, hadExpiredTasks, err := s.advanceIfBefore(until, limits.Check)
+			if err != nil {
+				return tasksProcessed, err
+			}
+
+			if !hadExpiredTasks {
+				return tasksProcessed, s.takeStrictModeViolation()
+			}
+
+			tasksProcessed++
+			continue
 		}
 
-		tasksProcessed++
+		_, _, hadExpiredTasks, err := s.advanceIfBefore(s.heldBefore(until), limits.Check)
+		if err != nil {
+			return tasksProcessed, err
+		}
+
+		if hadExpiredTasks {
+			tasksProcessed++
+			continue
+		}
+
+		select {
+		case <-s.wakeUp:
+		case <-ctx.Done():
+		}
 	}
 
 	return tasksProcessed, ctx.Err()
 }
 
+// Boundary defines whether tasks with deadline exactly at the target moment are processed.
+type Boundary int
+
+const (
+	BoundaryInclusive Boundary = iota
+	BoundaryExclusive
+)
+
+// Processes all tasks with deadline up to the specified moment (including), and then sets current time to it.
+// Unlike ProcessAllUntil, current time is moved to the target even if there were no tasks near it.
+func (s *Simulator) AdvanceTo(ctx context.Context, t time.Time) (int, error) {
+	return s.AdvanceToBoundary(ctx, t, BoundaryInclusive)
+}
+
+// Same as AdvanceTo, but the target moment is relative to the current time.
+func (s *Simulator) AdvanceBy(ctx context.Context, d time.Duration) (int, error) {
+	return s.AdvanceToBoundary(ctx, s.Now().Add(d), BoundaryInclusive)
+}
+
+// Same as AdvanceTo, but allows to choose whether tasks with deadline exactly at the target moment are processed.
+// If processing fails, current time is left at the moment of the last processed task.
+func (s *Simulator) AdvanceToBoundary(ctx context.Context, t time.Time, boundary Boundary) (int, error) {
+	defer s.runProcessingDoneHooks()
+
+	until := t
+	if boundary == BoundaryInclusive {
+		until = t.Add(1)
+	}
+
+	tasksProcessed, err := s.processAllUntil(ctx, until)
+	if err != nil {
+		return tasksProcessed, err
+	}
+
+	s.SetNow(t)
+
+	return tasksProcessed, nil
+}
+
+// Hold prevents ProcessAll and ProcessAllUntil from advancing time past the current moment
+// and from finishing when there are no more tasks. Processing continues normally once all holds are released.
+// Use it when tasks are scheduled from other goroutines: take a hold before starting such goroutine,
+// and release it after the goroutine scheduled its tasks.
+// Returned function releases the hold. It is safe to call it multiple times.
+func (s *Simulator) Hold() (release func()) {
+	s.holdsLock.Lock()
+	s.holds++
+	s.holdsLock.Unlock()
+
+	var releaseOnce sync.Once
+
+	return func() {
+		releaseOnce.Do(func() {
+			s.holdsLock.Lock()
+			s.holds--
+			s.holdsLock.Unlock()
+
+			s.notifyWakeUp()
+		})
+	}
+}
+
+// OnProcessingDone calls f each time ProcessAll, ProcessAllUntil or AdvanceTo* returns, including on error.
+// f is called in the goroutine of the processing method and receives current simulated time.
+// Returned function removes the hook. It is safe to call it multiple times.
+func (s *Simulator) OnProcessingDone(f func(now time.Time)) (remove func()) {
+	hook := &f
+
+	s.processingDoneLock.Lock()
+	s.processingDoneHooks = append(s.processingDoneHooks, hook)
+	s.processingDoneLock.Unlock()
+
+	return func() {
+		s.processingDoneLock.Lock()
+		defer s.processingDoneLock.Unlock()
+
+		for i, h := range s.processingDoneHooks {
+			if h == hook {
+				s.processingDoneHooks = append(s.processingDoneHooks[:i:i], s.processingDoneHooks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (s *Simulator) runProcessingDoneHooks() {
+	s.processingDoneLock.Lock()
+	hooks := s.processingDoneHooks
+	s.processingDoneLock.Unlock()
+
+	now := s.Now()
+
+	for _, hook := range hooks {
+		(*hook)(now)
+	}
+}
+
+func (s *Simulator) isHeld() bool {
+	s.holdsLock.Lock()
+	defer s.holdsLock.Unlock()
+
+	return s.holds > 0
+}
+
+// Returns the moment, before which tasks are allowed to be processed while simulator is held.
+func (s *Simulator) heldBefore(until time.Time) time.Time {
+	before := s.Now().Add(1)
+
+	if !until.IsZero() && until.Before(before) {
+		return until
+	}
+
+	return before
+}
+
+// Wakes up ProcessAll, if it is waiting for new tasks or for holds to be released.
+func (s *Simulator) notifyWakeUp() {
+	select {
+	case s.wakeUp <- struct{}{}:
+	default:
+	}
+}
+
 func (s *Simulator) HasExpiredTasks(before time.Time) bool {
 	s.usageLock.RLock()
 	defer s.usageLock.RUnlock()
@@ -141,59 +349,70 @@ func (s *Simulator) PopAllTasks() []*Task {
 	s.usageLock.Lock()
 	defer s.usageLock.Unlock()
 
-	tasks := s.taskQueue
-	s.taskQueue = newTaskQueue()
-
-	return []*Task(*tasks)
+	return s.taskQueue.PopAllTasks()
 }
 
-func (s *Simulator) processNextTask(keepLock bool) (time.Time, time.Duration) {
-	nextTask := s.taskQueue.PopTask()
+// Must be called with usage lock locked. Releases the lock while the task is running,
+// because tasks usually use the simulator.
+func (s *Simulator) processNextTask() (time.Time, time.Duration) {
+	nextTask := s.taskQueue.PopTaskForRun()
 	now, leap := s.setNow(nextTask.Deadline)
 	s.usageLock.Unlock()
 
 	followingTask := nextTask.Run(now)
 
-	if followingTask != nil {
-		s.usageLock.Lock()
-		s.taskQueue.PushTask(followingTask)
-		if !keepLock {
-			s.usageLock.Unlock()
-		}
-	} else if keepLock {
-		s.usageLock.Lock()
-	}
+	s.usageLock.Lock()
+	s.taskQueue.FinishTaskRun(nextTask, followingTask)
+	s.usageLock.Unlock()
 
 	return now, leap
 }
 
-func (t *Simulator) removeTask(task *Task) (taskWasActive bool) {
-	t.usageLock.Lock()
-	defer t.usageLock.Unlock()
+func (s *Simulator) postponeRunningTask(task *Task, d time.Duration, dropMissed bool) bool {
+	s.usageLock.Lock()
+	defer s.usageLock.Unlock()
 
-	if !task.IsPending() {
-		return false
-	}
+	return s.taskQueue.PostponeRunningTask(task, d, dropMissed, s.now)
+}
 
-	t.taskQueue.RemoveTask(task)
+func (s *Simulator) taskState(task *Task) taskState {
+	s.usageLock.RLock()
+	defer s.usageLock.RUnlock()
 
-	return true
+	return s.taskQueue.TaskState(task)
 }
 
-func (t *Simulator) resetTask(task *Task, d time.Duration) (wasPending bool) {
+func (t *Simulator) removeTask(task *Task, method string) (taskWasActive bool) {
+	t.checkCallerGoroutine(method)
+
 	t.usageLock.Lock()
 	defer t.usageLock.Unlock()
 
-	isPending := task.IsPending()
+	return t.taskQueue.CancelTask(task)
+}
 
-	if isPending {
-		t.taskQueue.RemoveTask(task)
-	}
+func (t *Simulator) resetTask(task *Task, d time.Duration, method string) (wasPending bool) {
+	t.checkCallerGoroutine(method)
+
+	t.usageLock.Lock()
+	defer t.usageLock.Unlock()
+
+	wasPending = t.taskQueue.RescheduleTask(task, t.now.Add(d))
+	t.notifyWakeUp()
+
+	return wasPending
+}
+
+func (t *Simulator) resetTaskAt(task *Task, deadline time.Time, method string) (wasPending bool) {
+	t.checkCallerGoroutine(method)
+
+	t.usageLock.Lock()
+	defer t.usageLock.Unlock()
 
-	task.Deadline = t.now.Add(d)
-	t.taskQueue.PushTask(task)
+	wasPending = t.taskQueue.RescheduleTask(task, deadline)
+	t.notifyWakeUp()
 
-	return isPending
+	return wasPending
 }
 
 func (s *Simulator) Since(t time.Time) time.Duration {
@@ -211,33 +430,56 @@ func (s *Simulator) Until(t time.Time) time.Duration {
 }
 
 func (s *Simulator) AfterFunc(d time.Duration, f func(now time.Time)) Timer {
+	s.checkCallerGoroutine("AfterFunc")
+
 	s.usageLock.Lock()
 	defer s.usageLock.Unlock()
 
 	timer, timerTask := newSimTimer(s, s.now.Add(d), f)
 	s.taskQueue.PushTask(timerTask)
+	s.notifyWakeUp()
 
 	return timer
 }
 
 func (s *Simulator) UntilFunc(t time.Time, f func(now time.Time)) Timer {
+	s.checkCallerGoroutine("UntilFunc")
+
 	s.usageLock.Lock()
 	defer s.usageLock.Unlock()
 
 	timer, fireTask := newSimTimer(s, t, f)
 
 	s.taskQueue.PushTask(fireTask)
+	s.notifyWakeUp()
 
 	return timer
 }
 
 func (s *Simulator) EveryFunc(interval time.Duration, f func(now time.Time) bool) Ticker {
+	s.checkCallerGoroutine("EveryFunc")
+
 	s.usageLock.Lock()
 	defer s.usageLock.Unlock()
 
 	ticker, startTask := newSimTicker(s, s.now.Add(interval), interval, f)
 
 	s.taskQueue.PushTask(startTask)
+	s.notifyWakeUp()
+
+	return ticker
+}
+
+func (s *Simulator) RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	s.checkCallerGoroutine("RepeatFunc")
+
+	s.usageLock.Lock()
+	defer s.usageLock.Unlock()
+
+	ticker, startTask := newSimRepeatTicker(s, "repeater", f, s.now.Add(d), d, false, f)
+
+	s.taskQueue.PushTask(startTask)
+	s.notifyWakeUp()
 
 	return ticker
 }
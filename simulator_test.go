@@ -0,0 +1,259 @@
+package chrono_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulatorHold(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+
+	var res []int
+
+	s.AfterFunc(365*24*time.Hour, func(now time.Time) {
+		res = append(res, 2)
+	})
+
+	s.AfterFunc(0, func(now time.Time) {
+		release := s.Hold()
+
+		go func() {
+			defer release()
+
+			time.Sleep(100 * time.Millisecond)
+
+			s.AfterFunc(time.Hour, func(now time.Time) {
+				require.Equal(t, start.Add(time.Hour), now)
+				res = append(res, 1)
+			})
+		}()
+	})
+
+	tasksProcessed, err := s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, tasksProcessed)
+	require.Equal(t, []int{1, 2}, res)
+}
+
+func TestSimulatorHoldOnEmptyQueue(t *testing.T) {
+	t.Parallel()
+
+	s := chrono.NewSimulator(time.Now())
+
+	release := s.Hold()
+	fired := false
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+
+		s.AfterFunc(time.Minute, func(now time.Time) {
+			fired = true
+		})
+
+		release()
+		release()
+	}()
+
+	_, err := s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.True(t, fired)
+
+	releaseForever := s.Hold()
+	defer releaseForever()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = s.ProcessAll(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSimulatorStrictMode(t *testing.T) {
+	t.Parallel()
+
+	runWithForeignScheduling := func(s *chrono.Simulator) (int, error) {
+		s.AfterFunc(time.Minute, func(now time.Time) {
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+				defer func() { recover() }()
+
+				s.AfterFunc(time.Minute, func(now time.Time) {})
+			}()
+
+			<-done
+		})
+
+		s.AfterFunc(time.Hour, func(now time.Time) {})
+
+		return s.ProcessAll(context.Background())
+	}
+
+	s := chrono.NewSimulator(time.Now())
+	tasksProcessed, err := runWithForeignScheduling(s)
+	require.NoError(t, err)
+	require.Equal(t, 3, tasksProcessed)
+
+	var violations []error
+	s = chrono.NewSimulator(time.Now())
+	s.SetStrictMode(chrono.StrictModeError, func(err error) {
+		violations = append(violations, err)
+	})
+	tasksProcessed, err = runWithForeignScheduling(s)
+	require.ErrorIs(t, err, chrono.ErrForeignGoroutine)
+	require.Equal(t, "AfterFunc", err.(*chrono.ForeignGoroutineError).Method)
+	require.Equal(t, 1, tasksProcessed)
+	require.Equal(t, []error{err}, violations)
+
+	var panicked interface{}
+	s = chrono.NewSimulator(time.Now())
+	s.SetStrictMode(chrono.StrictModePanic, nil)
+	s.AfterFunc(time.Minute, func(now time.Time) {
+		timer := s.AfterFunc(time.Minute, func(now time.Time) {})
+		done := make(chan struct{})
+
+		go func() {
+			defer close(done)
+			defer func() { panicked = recover() }()
+
+			timer.Stop()
+		}()
+
+		<-done
+	})
+	_, err = s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.ErrorIs(t, panicked.(error), chrono.ErrForeignGoroutine)
+
+	// Scheduling from other goroutines is allowed when processing is not active
+	s.AfterFunc(time.Minute, func(now time.Time) {})
+}
+
+func TestSimulatorLimits(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	s.SetLimits(chrono.SimulatorLimits{MaxTasksPerInstant: 10})
+
+	var reschedule func(now time.Time)
+	reschedule = func(now time.Time) {
+		s.AfterFunc(0, reschedule)
+	}
+
+	s.AfterFunc(time.Minute, reschedule)
+
+	tasksProcessed, err := s.ProcessAll(context.Background())
+	require.ErrorIs(t, err, chrono.ErrLimitExceeded)
+	require.Equal(t, 10, tasksProcessed)
+
+	var limitErr *chrono.LimitExceededError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, "MaxTasksPerInstant", limitErr.Limit)
+	require.Contains(t, limitErr.Task, "TestSimulatorLimits")
+	require.Equal(t, start.Add(time.Minute), limitErr.Deadline)
+
+	s = chrono.NewSimulator(start)
+	s.SetLimits(chrono.SimulatorLimits{MaxTotalTasks: 5})
+	s.EveryFunc(time.Second, func(now time.Time) bool { return true })
+
+	tasksProcessed, err = s.ProcessAll(context.Background())
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, "MaxTotalTasks", limitErr.Limit)
+	require.Equal(t, 5, tasksProcessed)
+	require.Equal(t, start.Add(5*time.Second), s.Now())
+
+	s.SetLimits(chrono.SimulatorLimits{MaxHorizon: time.Minute})
+
+	tasksProcessed, err = s.ProcessAll(context.Background())
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, "MaxHorizon", limitErr.Limit)
+	require.Equal(t, 60, tasksProcessed)
+	require.Equal(t, start.Add(65*time.Second), s.Now())
+}
+
+func TestSimulatorAdvanceTo(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+
+	var res []int
+
+	for i := 1; i <= 3; i++ {
+		i := i
+		s.AfterFunc(time.Duration(i)*time.Minute, func(now time.Time) {
+			res = append(res, i)
+		})
+	}
+
+	tasksProcessed, err := s.AdvanceTo(context.Background(), start.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, tasksProcessed)
+	require.Equal(t, []int{1}, res)
+	require.Equal(t, start.Add(time.Minute), s.Now())
+
+	tasksProcessed, err = s.AdvanceToBoundary(context.Background(), start.Add(2*time.Minute), chrono.BoundaryExclusive)
+	require.NoError(t, err)
+	require.Equal(t, 0, tasksProcessed)
+	require.Equal(t, []int{1}, res)
+	require.Equal(t, start.Add(2*time.Minute), s.Now())
+
+	tasksProcessed, err = s.AdvanceBy(context.Background(), 90*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 2, tasksProcessed)
+	require.Equal(t, []int{1, 2, 3}, res)
+	require.Equal(t, start.Add(210*time.Second), s.Now())
+}
+
+func TestSimulatorStrictModeWithHold(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	s.SetStrictMode(chrono.StrictModePanic, nil)
+
+	client, server := chrono.Pipe(s, chrono.PipeOpts{Latency: time.Second})
+
+	var received []byte
+	var scheduledAt time.Time
+
+	s.AfterFunc(time.Minute, func(now time.Time) {
+		release := s.Hold()
+
+		go func() {
+			defer release()
+
+			// Scheduling from foreign goroutine is allowed while simulator is held
+			client.Write([]byte("ping"))
+			s.AfterFunc(time.Minute, func(now time.Time) {
+				scheduledAt = now
+			})
+		}()
+	})
+
+	readDone := make(chan struct{})
+
+	go func() {
+		defer close(readDone)
+
+		buf := make([]byte, 4)
+		n, _ := server.Read(buf)
+		received = buf[:n]
+	}()
+
+	_, err := s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	<-readDone
+
+	require.Equal(t, "ping", string(received))
+	require.Equal(t, start.Add(2*time.Minute), scheduledAt)
+}
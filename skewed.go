@@ -0,0 +1,210 @@
+package chrono
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// NewSkewedClock creates clock, which time is off from the underlying clock (usually Simulator).
+// It is used to test components for robustness against wrong or drifting clocks of the nodes they run on.
+//
+// Observed time is the true time of the underlying clock plus:
+//   - constant offset;
+//   - drift: driftRate * (true time passed since the creation of the clock). E.g. 50e-6 means +50 µs per second;
+//   - scripted steps (see AddStep) - sudden jumps like ones made by NTP.
+//
+// Like real system timers, which use monotonic clock, durations are affected only by the drift:
+// the clock running faster fires its timers earlier. Steps do not move already scheduled timers,
+// and absolute deadlines (UntilFunc, ResetAt) are converted into durations at the moment of scheduling.
+// The underlying clock still orders all the tasks by true time.
+func NewSkewedClock(c Clock, offset time.Duration, driftRate float64) *SkewedClock {
+	if driftRate <= -1 {
+		panic("drift rate must be greater than -1")
+	}
+
+	return &SkewedClock{
+		clock:       c,
+		offset:      offset,
+		driftRate:   driftRate,
+		driftOrigin: c.Now(),
+	}
+}
+
+type SkewedClock struct {
+	clock Clock
+
+	lock        sync.RWMutex
+	offset      time.Duration
+	driftRate   float64
+	driftOrigin time.Time
+	steps       []clockStep
+}
+
+type clockStep struct {
+	at   time.Time
+	step time.Duration
+}
+
+var _ Clock = &SkewedClock{}
+
+// AddStep schedules a jump of the observed time by the step at specified moment of true time.
+func (c *SkewedClock) AddStep(at time.Time, step time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.steps = append(c.steps, clockStep{at: at, step: step})
+	sort.SliceStable(c.steps, func(i, j int) bool {
+		return c.steps[i].at.Before(c.steps[j].at)
+	})
+}
+
+// Step makes observed time jump by the step immediately.
+func (c *SkewedClock) Step(step time.Duration) {
+	c.AddStep(c.clock.Now(), step)
+}
+
+// Returns observed time, which corresponds to the moment of true time.
+func (c *SkewedClock) ObservedTime(trueTime time.Time) time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.observedTime(trueTime)
+}
+
+// Must be called under lock.
+func (c *SkewedClock) observedTime(trueTime time.Time) time.Time {
+	observed := trueTime.Add(c.offset)
+	observed = observed.Add(time.Duration(c.driftRate * float64(trueTime.Sub(c.driftOrigin))))
+
+	for _, step := range c.steps {
+		if step.at.After(trueTime) {
+			break
+		}
+
+		observed = observed.Add(step.step)
+	}
+
+	return observed
+}
+
+// Converts duration measured by this clock into true duration.
+func (c *SkewedClock) trueDuration(d time.Duration) time.Duration {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return time.Duration(float64(d) / (1 + c.driftRate))
+}
+
+// Converts true duration into duration measured by this clock.
+func (c *SkewedClock) observedDuration(d time.Duration) time.Duration {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return time.Duration(float64(d) * (1 + c.driftRate))
+}
+
+// Converts observed deadline into true one, assuming there are no steps until then.
+func (c *SkewedClock) trueDeadline(deadline time.Time) time.Time {
+	trueNow := c.clock.Now()
+	return trueNow.Add(c.trueDuration(deadline.Sub(c.ObservedTime(trueNow))))
+}
+
+func (c *SkewedClock) Now() time.Time {
+	return c.ObservedTime(c.clock.Now())
+}
+
+func (c *SkewedClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *SkewedClock) Until(t time.Time) time.Duration {
+	return t.Sub(c.Now())
+}
+
+func (c *SkewedClock) AfterFunc(d time.Duration, f func(now time.Time)) Timer {
+	return &skewedTimer{
+		Timer: c.clock.AfterFunc(c.trueDuration(d), c.wrap(f)),
+		c:     c,
+	}
+}
+
+func (c *SkewedClock) UntilFunc(t time.Time, f func(now time.Time)) Timer {
+	return &skewedTimer{
+		Timer: c.clock.UntilFunc(c.trueDeadline(t), c.wrap(f)),
+		c:     c,
+	}
+}
+
+func (c *SkewedClock) EveryFunc(d time.Duration, f func(now time.Time) bool) Ticker {
+	return &skewedTicker{
+		Ticker: c.clock.EveryFunc(c.trueDuration(d), func(now time.Time) bool {
+			return f(c.ObservedTime(now))
+		}),
+		c: c,
+	}
+}
+
+func (c *SkewedClock) RepeatFunc(d time.Duration, f func(now time.Time) (next time.Duration, contin bool)) Ticker {
+	return &skewedTicker{
+		Ticker: RepeatFunc(c.clock, c.trueDuration(d), func(now time.Time) (time.Duration, bool) {
+			next, contin := f(c.ObservedTime(now))
+			return c.trueDuration(next), contin
+		}),
+		c: c,
+	}
+}
+
+func (c *SkewedClock) wrap(f func(now time.Time)) func(now time.Time) {
+	return func(now time.Time) {
+		f(c.ObservedTime(now))
+	}
+}
+
+type skewedTimer struct {
+	Timer
+	c *SkewedClock
+}
+
+func (t *skewedTimer) Reset(d time.Duration) bool {
+	return t.Timer.Reset(t.c.trueDuration(d))
+}
+
+func (t *skewedTimer) ResetAt(deadline time.Time) bool {
+	return t.Timer.ResetAt(t.c.trueDeadline(deadline))
+}
+
+func (t *skewedTimer) Deadline() time.Time {
+	deadline := t.Timer.Deadline()
+	if deadline.IsZero() {
+		return deadline
+	}
+
+	return t.c.ObservedTime(deadline)
+}
+
+type skewedTicker struct {
+	Ticker
+	c *SkewedClock
+}
+
+func (t *skewedTicker) Reset(d time.Duration) {
+	t.Ticker.Reset(t.c.trueDuration(d))
+}
+
+func (t *skewedTicker) ResetAt(deadline time.Time) {
+	t.Ticker.ResetAt(t.c.trueDeadline(deadline))
+}
+
+func (t *skewedTicker) Deadline() time.Time {
+	deadline := t.Ticker.Deadline()
+	if deadline.IsZero() {
+		return deadline
+	}
+
+	return t.c.ObservedTime(deadline)
+}
+
+func (t *skewedTicker) Period() time.Duration {
+	return t.c.observedDuration(t.Ticker.Period())
+}
@@ -0,0 +1,52 @@
+package chrono_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkewedClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := chrono.NewSimulator(start)
+
+	// Node clock is one hour ahead and runs twice faster than true time
+	c := chrono.NewSkewedClock(s, time.Hour, 1)
+	c.AddStep(start.Add(2*time.Minute), 30*time.Minute)
+
+	require.Equal(t, start.Add(time.Hour), c.Now())
+
+	var res []string
+	var observed []time.Time
+
+	timer := c.AfterFunc(2*time.Minute, func(now time.Time) {
+		res = append(res, "skewed timer")
+		observed = append(observed, now)
+	})
+	require.Equal(t, start.Add(time.Hour+2*time.Minute), timer.Deadline())
+
+	s.AfterFunc(90*time.Second, func(now time.Time) {
+		res = append(res, "true timer")
+	})
+
+	ticker := c.EveryFunc(4*time.Minute, func(now time.Time) bool {
+		res = append(res, "skewed ticker")
+		observed = append(observed, now)
+		return false
+	})
+	require.Equal(t, 4*time.Minute, ticker.Period())
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []string{"skewed timer", "true timer", "skewed ticker"}, res)
+	require.Equal(t, []time.Time{
+		start.Add(time.Hour + 2*time.Minute),
+		// True time 2m + offset 1h + drift 2m + step 30m
+		start.Add(time.Hour + 34*time.Minute),
+	}, observed)
+}
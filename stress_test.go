@@ -0,0 +1,162 @@
+package chrono_test
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nnikolash/go-chrono"
+	"github.com/stretchr/testify/require"
+)
+
+// These tests are meant to be run with -race flag.
+
+func TestSimulatorStressConcurrentUsage(t *testing.T) {
+	t.Parallel()
+
+	const goroutines = 8
+	const iterations = 300
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+
+	var scheduled, fired atomic.Int64
+	var lastNow time.Time
+
+	onFire := func(now time.Time) {
+		// Handlers are executed in single goroutine, so no synchronization is needed
+		require.False(t, now.Before(lastNow), "time went backward")
+		require.False(t, now.Before(start))
+		lastNow = now
+		fired.Add(1)
+	}
+
+	release := s.Hold()
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < goroutines; g++ {
+		rnd := rand.New(rand.NewSource(int64(g)))
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			var timers []chrono.Timer
+			var tickers []chrono.Ticker
+
+			for i := 0; i < iterations; i++ {
+				d := time.Duration(rnd.Intn(1000)) * time.Millisecond
+
+				switch rnd.Intn(6) {
+				case 0, 1:
+					scheduled.Add(1)
+					timers = append(timers, s.AfterFunc(d, onFire))
+				case 2:
+					scheduled.Add(1)
+					timers = append(timers, s.UntilFunc(s.Now().Add(d), onFire))
+				case 3:
+					if len(timers) != 0 {
+						timer := timers[rnd.Intn(len(timers))]
+						if rnd.Intn(2) == 0 {
+							timer.Stop()
+						} else {
+							scheduled.Add(1)
+							timer.Reset(d)
+						}
+					}
+				case 4:
+					ticks := 0
+					tickers = append(tickers, s.EveryFunc(d+time.Millisecond, func(now time.Time) bool {
+						onFire(now)
+						ticks++
+						return ticks < 3
+					}))
+				case 5:
+					if len(tickers) != 0 {
+						ticker := tickers[rnd.Intn(len(tickers))]
+						if rnd.Intn(2) == 0 {
+							ticker.Stop()
+						} else {
+							ticker.Reset(d + time.Millisecond)
+						}
+					}
+				}
+
+				_ = s.Now()
+				_ = s.Since(start)
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		release()
+	}()
+
+	_, err := s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, s.PopAllTasks())
+	require.NotZero(t, fired.Load())
+	require.NotZero(t, scheduled.Load())
+}
+
+func TestSimulatorStressStopResetRunningTasks(t *testing.T) {
+	t.Parallel()
+
+	s := chrono.NewSimulator(time.Now())
+
+	const tickersCount = 50
+
+	tickers := make([]chrono.Ticker, tickersCount)
+	var ticks [tickersCount]atomic.Int64
+
+	for i := range tickers {
+		i := i
+		tickers[i] = s.EveryFunc(time.Millisecond, func(now time.Time) bool {
+			ticks[i].Add(1)
+			return true
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	for i := range tickers {
+		i := i
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for ticks[i].Load() < 10 {
+				tickers[i].Reset(time.Millisecond)
+				time.Sleep(time.Microsecond)
+			}
+
+			tickers[i].Stop()
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	_, err := s.ProcessAll(ctx)
+	if err != nil {
+		require.ErrorIs(t, err, context.Canceled)
+	}
+
+	wg.Wait()
+
+	// All tickers are stopped, so the queue must drain
+	_, err = s.ProcessAll(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, s.PopAllTasks())
+}
@@ -0,0 +1,162 @@
+package chrono
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// StrictMode defines how simulator reacts to scheduling from foreign goroutines.
+type StrictMode int32
+
+const (
+	// Foreign goroutines are not detected.
+	StrictModeDisabled StrictMode = iota
+	// Violating call panics in the foreign goroutine.
+	StrictModePanic
+	// ProcessAll stops after current task and returns the violation error.
+	StrictModeError
+	// Violation is only reported to the callback.
+	StrictModeCallback
+)
+
+var ErrForeignGoroutine = errors.New("simulator is used from foreign goroutine")
+
+// ForeignGoroutineError is reported when simulator tasks are scheduled, stopped or reset
+// from a goroutine other than the one running ProcessAll, while processing is active.
+type ForeignGoroutineError struct {
+	Method              string
+	ProcessingGoroutine uint64
+	CallerGoroutine     uint64
+}
+
+func (e *ForeignGoroutineError) Error() string {
+	return fmt.Sprintf("%v: %v called from goroutine %v while simulation runs in goroutine %v",
+		ErrForeignGoroutine, e.Method, e.CallerGoroutine, e.ProcessingGoroutine)
+}
+
+func (e *ForeignGoroutineError) Unwrap() error {
+	return ErrForeignGoroutine
+}
+
+type strictModeState struct {
+	mode                atomic.Int32
+	processingGoroutine atomic.Uint64
+
+	lock        sync.Mutex
+	onViolation func(err error)
+	violation   error
+}
+
+// SetStrictMode enables detection of AfterFunc, UntilFunc, EveryFunc and Stop/Reset of timers and tickers
+// being called from foreign goroutines while ProcessAll or ProcessAllUntil is running.
+// Such calls usually make simulation nondeterministic (see Hold and Inbox for the proper ways).
+// Calls made while there are outstanding holds are not reported, because time does not advance
+// until the holds are released (e.g. Pipe used from other goroutines under Hold).
+// If onViolation is not nil, it is called from the violating goroutine for every violation in any of the modes.
+// Must be called before processing is started.
+func (s *Simulator) SetStrictMode(mode StrictMode, onViolation func(err error)) {
+	s.strict.lock.Lock()
+	defer s.strict.lock.Unlock()
+
+	if mode == StrictModeCallback && onViolation == nil {
+		panic("violation callback is required for callback strict mode")
+	}
+
+	s.strict.mode.Store(int32(mode))
+	s.strict.onViolation = onViolation
+	s.strict.violation = nil
+}
+
+// Remembers current goroutine as processing one. Returned function must be called when processing is finished.
+func (s *Simulator) beginStrictProcessing() (end func()) {
+	if StrictMode(s.strict.mode.Load()) == StrictModeDisabled {
+		return func() {}
+	}
+
+	if !s.strict.processingGoroutine.CompareAndSwap(0, currentGoroutineID()) {
+		// Nested processing - goroutine is already remembered
+		return func() {}
+	}
+
+	return func() {
+		s.strict.processingGoroutine.Store(0)
+	}
+}
+
+func (s *Simulator) checkCallerGoroutine(method string) {
+	mode := StrictMode(s.strict.mode.Load())
+	if mode == StrictModeDisabled {
+		return
+	}
+
+	processingGoroutine := s.strict.processingGoroutine.Load()
+	if processingGoroutine == 0 {
+		return
+	}
+
+	callerGoroutine := currentGoroutineID()
+	if callerGoroutine == processingGoroutine || s.isHeld() {
+		return
+	}
+
+	err := &ForeignGoroutineError{
+		Method:              method,
+		ProcessingGoroutine: processingGoroutine,
+		CallerGoroutine:     callerGoroutine,
+	}
+
+	s.strict.lock.Lock()
+	onViolation := s.strict.onViolation
+	if mode == StrictModeError && s.strict.violation == nil {
+		s.strict.violation = err
+	}
+	s.strict.lock.Unlock()
+
+	if onViolation != nil {
+		onViolation(err)
+	}
+
+	if mode == StrictModePanic {
+		panic(err)
+	}
+}
+
+// Returns and clears the violation, registered in StrictModeError mode.
+func (s *Simulator) takeStrictModeViolation() error {
+	if StrictMode(s.strict.mode.Load()) != StrictModeError {
+		return nil
+	}
+
+	s.strict.lock.Lock()
+	defer s.strict.lock.Unlock()
+
+	err := s.strict.violation
+	s.strict.violation = nil
+
+	return err
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// Returns ID of current goroutine. It is parsed from the stack trace, so it is not fast.
+func currentGoroutineID() uint64 {
+	var buf [64]byte
+	stack := buf[:runtime.Stack(buf[:], false)]
+
+	stack = bytes.TrimPrefix(stack, goroutinePrefix)
+	if i := bytes.IndexByte(stack, ' '); i >= 0 {
+		stack = stack[:i]
+	}
+
+	id, err := strconv.ParseUint(string(stack), 10, 64)
+	if err != nil {
+		panic(fmt.Sprintf("failed to parse goroutine id: %v", err))
+	}
+
+	return id
+}
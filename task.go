@@ -2,17 +2,36 @@ package chrono
 
 import (
 	"container/heap"
+	"reflect"
+	"runtime"
 	"time"
 )
 
-type taskQueue []*Task
+// Queue of tasks ordered by deadline.
+type taskQueue struct {
+	tasks   []*Task
+	fifo    bool
+	nextSeq uint64
+}
 
 func newTaskQueue() *taskQueue {
-	t := make(taskQueue, 0, 100)
-	return &t
+	return &taskQueue{
+		tasks: make([]*Task, 0, 100),
+	}
+}
+
+// Same as newTaskQueue, but tasks with the same deadline are ordered by the time they were pushed.
+func newFIFOTaskQueue() *taskQueue {
+	q := newTaskQueue()
+	q.fifo = true
+
+	return q
 }
 
 func (q *taskQueue) PushTask(t *Task) {
+	t.seq = q.nextSeq
+	q.nextSeq++
+
 	heap.Push(q, t)
 }
 
@@ -22,15 +41,15 @@ func (q *taskQueue) PopTask() (_ *Task) {
 }
 
 func (q *taskQueue) PeekTask() (_ *Task) {
-	return (*q)[0]
+	return q.tasks[0]
 }
 
-func (q taskQueue) HasExpiredTasks(now time.Time) bool {
-	return len(q) != 0 && !now.Before(q[0].Deadline)
+func (q *taskQueue) HasExpiredTasks(now time.Time) bool {
+	return len(q.tasks) != 0 && !now.Before(q.tasks[0].Deadline)
 }
 
-func (q taskQueue) HasTasks() bool {
-	return len(q) != 0
+func (q *taskQueue) HasTasks() bool {
+	return len(q.tasks) != 0
 }
 
 func (q *taskQueue) RemoveTask(t *Task) {
@@ -39,31 +58,123 @@ func (q *taskQueue) RemoveTask(t *Task) {
 	}
 }
 
-func (q taskQueue) Len() int { return len(q) }
+// Pops next task to be run. Task is considered running until FinishTaskRun is called.
+func (q *taskQueue) PopTaskForRun() *Task {
+	t := q.PopTask()
+	t.cancelled = false
+	t.running = true
+
+	return t
+}
+
+// Pushes following task, returned by the task run.
+// If the task was stopped or reset while running, its own rescheduling is ignored.
+func (q *taskQueue) FinishTaskRun(t *Task, followingTask *Task) {
+	t.running = false
+
+	if followingTask == nil {
+		return
+	}
+
+	if followingTask != t || (!t.cancelled && !t.IsPending()) {
+		q.PushTask(followingTask)
+	}
+}
+
+// Removes task from the queue. If task is not in the queue (e.g. it is running), prevents its rescheduling.
+func (q *taskQueue) CancelTask(t *Task) (wasPending bool) {
+	if !t.IsPending() {
+		t.cancelled = true
+		return false
+	}
+
+	q.RemoveTask(t)
+
+	return true
+}
+
+// Sets new deadline of the task and puts it into the queue, if it is not there yet.
+func (q *taskQueue) RescheduleTask(t *Task, deadline time.Time) (wasPending bool) {
+	wasPending = t.IsPending()
+	t.cancelled = false
+
+	if wasPending {
+		q.RemoveTask(t)
+	}
+
+	t.Deadline = deadline
+	q.PushTask(t)
+
+	return wasPending
+}
+
+// Moves the deadline of the running task, which is going to be rescheduled.
+// Returns false if the task was stopped or reset while running.
+// If dropMissed is true, deadlines, which are not after now, are skipped. In this case d must be positive.
+func (q *taskQueue) PostponeRunningTask(t *Task, d time.Duration, dropMissed bool, now time.Time) bool {
+	if t.cancelled || t.IsPending() {
+		return false
+	}
+
+	t.Deadline = t.Deadline.Add(d)
+
+	for dropMissed && !t.Deadline.After(now) {
+		t.Deadline = t.Deadline.Add(d)
+	}
+
+	return true
+}
+
+func (q *taskQueue) TaskState(t *Task) taskState {
+	return taskState{
+		Deadline:  t.Deadline,
+		Pending:   t.IsPending(),
+		Running:   t.running,
+		Cancelled: t.cancelled,
+	}
+}
+
+// Removes all the tasks from the queue and returns them.
+func (q *taskQueue) PopAllTasks() []*Task {
+	tasks := q.tasks
+	q.tasks = make([]*Task, 0, 100)
+
+	for _, task := range tasks {
+		task.indexInQueue = -1
+	}
+
+	return tasks
+}
+
+func (q *taskQueue) Len() int { return len(q.tasks) }
 
-func (q taskQueue) Less(i, j int) bool {
-	return q[i].Deadline.Before(q[j].Deadline)
+func (q *taskQueue) Less(i, j int) bool {
+	if q.fifo && q.tasks[i].Deadline.Equal(q.tasks[j].Deadline) {
+		return q.tasks[i].seq < q.tasks[j].seq
+	}
+
+	return q.tasks[i].Deadline.Before(q.tasks[j].Deadline)
 }
 
-func (q taskQueue) Swap(i, j int) {
-	q[i], q[j] = q[j], q[i]
-	q[i].indexInQueue, q[j].indexInQueue = i, j
+func (q *taskQueue) Swap(i, j int) {
+	q.tasks[i], q.tasks[j] = q.tasks[j], q.tasks[i]
+	q.tasks[i].indexInQueue, q.tasks[j].indexInQueue = i, j
 }
 
 func (q *taskQueue) Push(v interface{}) {
 	task := v.(*Task)
-	task.indexInQueue = len(*q)
-	*q = append(*q, task)
+	task.indexInQueue = len(q.tasks)
+	q.tasks = append(q.tasks, task)
 }
 
 func (q *taskQueue) Pop() interface{} {
-	tasks := *q
+	tasks := q.tasks
 	n := len(tasks)
 
 	oldestTask := tasks[n-1]
 	oldestTask.indexInQueue = -1
 
-	*q = tasks[0 : n-1]
+	q.tasks = tasks[0 : n-1]
 
 	return oldestTask
 }
@@ -72,14 +183,39 @@ type Task struct {
 	Deadline     time.Time
 	Action       func(t *Task, now time.Time) (followingTask *Task)
 	indexInQueue int
+	seq          uint64
+	// Set when task is stopped while not being in queue, e.g. while it is running.
+	cancelled bool
+	running   bool
+	// Kind and handler describe the task in errors. Name is resolved only when needed, because it is expensive.
+	kind    string
+	handler any
+}
+
+type taskState struct {
+	Deadline  time.Time
+	Pending   bool
+	Running   bool
+	Cancelled bool
 }
 
-func newTask(deadline time.Time, run func(t *Task, now time.Time) *Task) *Task {
+func newTask(kind string, handler any, deadline time.Time, run func(t *Task, now time.Time) *Task) *Task {
 	return &Task{
 		Deadline:     deadline,
 		Action:       run,
 		indexInQueue: -1,
+		kind:         kind,
+		handler:      handler,
+	}
+}
+
+// Name describes the task in errors and reports.
+func (t *Task) Name() string {
+	if t.handler == nil {
+		return t.kind
 	}
+
+	return t.kind + " " + funcName(t.handler)
 }
 
 func (t *Task) Run(now time.Time) (followingTask *Task) {
@@ -89,3 +225,24 @@ func (t *Task) Run(now time.Time) (followingTask *Task) {
 func (t Task) IsPending() bool {
 	return t.indexInQueue != -1
 }
+
+// Clock, which runs its timers and tickers as tasks of a task queue.
+type taskScheduler interface {
+	removeTask(task *Task, method string) (wasPending bool)
+	resetTask(task *Task, d time.Duration, method string) (wasPending bool)
+	resetTaskAt(task *Task, deadline time.Time, method string) (wasPending bool)
+	// Moves the deadline of the running task, if it was not stopped or reset while running.
+	// If dropMissed is true, deadlines, which are already passed, are skipped.
+	postponeRunningTask(task *Task, d time.Duration, dropMissed bool) bool
+	taskState(task *Task) taskState
+}
+
+// Returns the name of the function to be used as part of the task name.
+func funcName(f interface{}) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+
+	return fn.Name()
+}
@@ -1,40 +1,466 @@
 package chrono
 
-import "time"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type Ticker interface {
+	// Reschedules next tick to happen after specified duration. Following ticks happen with the original period.
+	// Restarts stopped ticker.
 	Reset(d time.Duration)
+	// Same as Reset, but next tick happens at specified moment.
+	ResetAt(t time.Time)
 	Stop()
+	// Returns the moment of the next tick, or zero time if the ticker is not active.
+	Deadline() time.Time
+	// Returns true if the ticker is going to tick.
+	Active() bool
+	// Returns how many times the ticker has ticked.
+	FireCount() int
+	// Returns the interval between ticks. For tickers created by RepeatFunc it is the last delay chosen by the handler.
+	Period() time.Duration
 }
 
-func newSimTicker(sim *Simulator, startTime time.Time, period time.Duration, action func(now time.Time) bool) (*simTicker, *Task) {
+// Converts EveryFunc handler into RepeatFunc handler.
+func periodicAction(period time.Duration, f func(now time.Time) bool) func(now time.Time) (time.Duration, bool) {
+	return func(now time.Time) (time.Duration, bool) {
+		return period, f(now)
+	}
+}
+
+// Like ticker of RealClock, it drops ticks missed because of slow handler. In simulation ticks can be missed
+// only if the handler moves current time with SetNow.
+func newSimTicker(sim taskScheduler, startTime time.Time, period time.Duration, action func(now time.Time) bool) (*simTicker, *Task) {
+	return newSimRepeatTicker(sim, "ticker", action, startTime, period, true, periodicAction(period, action))
+}
+
+// Ticker, which handler chooses the delay until the next tick.
+// The delay is counted from the deadline of the current tick. Non-positive delay means next tick happens immediately.
+// If the next tick is already missed, it is either dropped (for periodic tickers) or happens immediately.
+func newSimRepeatTicker(sim taskScheduler, kind string, handler any, startTime time.Time, firstDelay time.Duration, dropMissed bool, action func(now time.Time) (time.Duration, bool)) (*simTicker, *Task) {
 	t := &simTicker{
 		sim: sim,
-		task: newTask(startTime, func(task *Task, now time.Time) *Task {
-			if !action(now) {
-				return nil
-			}
+	}
 
-			task.Deadline = task.Deadline.Add(period)
+	t.period.Store(int64(firstDelay))
 
-			return task
-		}),
-	}
+	t.task = newTask(kind, handler, startTime, func(task *Task, now time.Time) *Task {
+		t.fireCount.Add(1)
+
+		next, contin := action(now)
+		if !contin {
+			return nil
+		}
+
+		t.period.Store(int64(next))
+
+		if !sim.postponeRunningTask(task, max(next, 0), dropMissed) {
+			return nil
+		}
+
+		return task
+	})
 
 	return t, t.task
 }
 
+// Ticker of the clocks, which are based on task queue - Simulator and EventLoopClock.
 type simTicker struct {
-	sim  *Simulator
-	task *Task
+	sim       taskScheduler
+	task      *Task
+	period    atomic.Int64
+	fireCount atomic.Int64
 }
 
 var _ Ticker = &simTicker{}
 
 func (t *simTicker) Stop() {
-	t.sim.removeTask(t.task)
+	t.sim.removeTask(t.task, "Ticker.Stop")
 }
 
 func (t *simTicker) Reset(d time.Duration) {
-	t.sim.resetTask(t.task, d)
+	t.sim.resetTask(t.task, d, "Ticker.Reset")
+}
+
+func (t *simTicker) ResetAt(deadline time.Time) {
+	t.sim.resetTaskAt(t.task, deadline, "Ticker.ResetAt")
+}
+
+// While the ticker handler is running, the ticker is considered active unless it is stopped,
+// and its deadline is the one it will have if the handler decides to continue.
+func (t *simTicker) Deadline() time.Time {
+	state := t.sim.taskState(t.task)
+
+	switch {
+	case state.Pending:
+		return state.Deadline
+	case state.Running && !state.Cancelled:
+		return state.Deadline.Add(t.Period())
+	default:
+		return time.Time{}
+	}
+}
+
+func (t *simTicker) Active() bool {
+	state := t.sim.taskState(t.task)
+	return state.Pending || (state.Running && !state.Cancelled)
+}
+
+func (t *simTicker) FireCount() int {
+	return int(t.fireCount.Load())
+}
+
+func (t *simTicker) Period() time.Duration {
+	return time.Duration(t.period.Load())
+}
+
+// Ticker of RealClock. Does not use goroutines - each tick is scheduled with time.AfterFunc.
+// Like time.Ticker, it drops ticks missed because of slow handler.
+// Reset behaves same as for the simulator: next tick happens after specified duration,
+// and the following ticks happen with the original period. Reset restarts stopped ticker.
+// Returns false if the clock is closed and the ticker was not scheduled.
+func newRealTicker(c *RealClock, period time.Duration, f func(now time.Time) bool) (_ *realTicker, scheduled bool) {
+	if period <= 0 {
+		panic("non-positive interval for EveryFunc")
+	}
+
+	return newRealRepeatTicker(c, period, periodicAction(period, f), true)
+}
+
+// Ticker, which handler chooses the delay until the next tick.
+// The delay is counted from the deadline of the current tick.
+// If the next tick is already missed, it is either dropped (for periodic tickers) or happens immediately.
+func newRealRepeatTicker(c *RealClock, firstDelay time.Duration, action func(now time.Time) (time.Duration, bool), dropMissed bool) (_ *realTicker, scheduled bool) {
+	t := &realTicker{
+		c:          c,
+		action:     action,
+		dropMissed: dropMissed,
+	}
+
+	t.period.Store(int64(firstDelay))
+
+	t.lock.Lock()
+	scheduled = t.schedule(time.Now().Add(firstDelay))
+	t.lock.Unlock()
+
+	return t, scheduled
+}
+
+type realTicker struct {
+	c          *RealClock
+	action     func(now time.Time) (time.Duration, bool)
+	dropMissed bool
+
+	period    atomic.Int64
+	fireCount atomic.Int64
+
+	lock     sync.Mutex
+	timer    *time.Timer
+	deadline time.Time
+	running  bool
+	// Incremented on each Stop and Reset to invalidate already scheduled and running ticks.
+	generation uint64
+}
+
+var _ Ticker = &realTicker{}
+
+func (t *realTicker) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.cancel()
+}
+
+func (t *realTicker) Reset(d time.Duration) {
+	t.ResetAt(time.Now().Add(d))
+}
+
+func (t *realTicker) ResetAt(deadline time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.cancel()
+	t.schedule(deadline)
+}
+
+// Same as for tickers of the simulator, while the handler is running the deadline is the one
+// the ticker will have if the handler decides to continue.
+func (t *realTicker) Deadline() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch {
+	case t.timer == nil:
+		return time.Time{}
+	case t.running:
+		return t.nextDeadline(t.Period())
+	default:
+		return t.deadline
+	}
+}
+
+func (t *realTicker) Active() bool {
+	return t.isActive()
+}
+
+func (t *realTicker) FireCount() int {
+	return int(t.fireCount.Load())
+}
+
+func (t *realTicker) Period() time.Duration {
+	return time.Duration(t.period.Load())
+}
+
+// Must be called under lock.
+func (t *realTicker) cancel() {
+	t.generation++
+	t.running = false
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+		t.c.unregister(t)
+	}
+}
+
+// Must be called under lock. Returns false if the clock is closed.
+func (t *realTicker) schedule(deadline time.Time) bool {
+	if !t.c.register(t) {
+		return false
+	}
+
+	generation := t.generation
+	t.deadline = deadline
+	t.timer = time.AfterFunc(max(time.Until(deadline), 0), func() {
+		t.tick(generation)
+	})
+
+	return true
+}
+
+func (t *realTicker) isActive() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.timer != nil
+}
+
+func (t *realTicker) stopOnClose() {
+	t.Stop()
+}
+
+// Tickers work with durations, so they are not affected by wall clock jumps.
+func (t *realTicker) onWallClockJump() {}
+
+// Marks the tick as running. Returns false if the tick is not actual anymore.
+func (t *realTicker) startTick(generation uint64) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.generation != generation {
+		return false
+	}
+
+	t.running = true
+
+	return true
+}
+
+// Returns the deadline of the tick following the current one. Must be called under lock.
+func (t *realTicker) nextDeadline(next time.Duration) time.Time {
+	nextDeadline := t.deadline.Add(max(next, 0))
+
+	if t.dropMissed {
+		now := time.Now()
+
+		for !nextDeadline.After(now) {
+			nextDeadline = nextDeadline.Add(next)
+		}
+	}
+
+	return nextDeadline
+}
+
+func (t *realTicker) tick(generation uint64) {
+	var next time.Duration
+	var contin, ran bool
+
+	t.c.executeTaskIf(func(now time.Time) {
+		next, contin = t.action(now)
+	}, func() bool {
+		if !t.startTick(generation) {
+			return false
+		}
+
+		ran = true
+		t.fireCount.Add(1)
+
+		return true
+	})
+	if !ran {
+		return
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.generation != generation {
+		// Stopped or reset while running
+		return
+	}
+
+	t.running = false
+
+	if !contin {
+		t.cancel()
+		return
+	}
+
+	t.period.Store(int64(next))
+	t.schedule(t.nextDeadline(next))
+}
+
+// Ticker, which handler chooses the delay until the next tick, for clocks, which don't implement RepeatFunc natively.
+// Each tick is scheduled with UntilFunc of the clock, so ticks are not executed concurrently.
+func newTimerRepeatTicker(c Clock, firstDelay time.Duration, action func(now time.Time) (time.Duration, bool)) *timerRepeatTicker {
+	t := &timerRepeatTicker{
+		clock:  c,
+		action: action,
+	}
+
+	t.period.Store(int64(firstDelay))
+
+	t.lock.Lock()
+	t.schedule(c.Now().Add(firstDelay))
+	t.lock.Unlock()
+
+	return t
+}
+
+type timerRepeatTicker struct {
+	clock  Clock
+	action func(now time.Time) (time.Duration, bool)
+
+	period    atomic.Int64
+	fireCount atomic.Int64
+
+	lock     sync.Mutex
+	timer    Timer
+	deadline time.Time
+	active   bool
+	running  bool
+	// Incremented on each scheduling and Stop to ignore outdated ticks and rescheduling by the running handler.
+	generation uint64
+}
+
+var _ Ticker = &timerRepeatTicker{}
+
+func (t *timerRepeatTicker) Stop() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.cancel()
+}
+
+func (t *timerRepeatTicker) Reset(d time.Duration) {
+	t.ResetAt(t.clock.Now().Add(d))
+}
+
+func (t *timerRepeatTicker) ResetAt(deadline time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.cancel()
+	t.schedule(deadline)
+}
+
+// Same as for tickers of the simulator, while the handler is running the deadline is the one
+// the ticker will have if the handler decides to continue.
+func (t *timerRepeatTicker) Deadline() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch {
+	case !t.active:
+		return time.Time{}
+	case t.running:
+		return t.deadline.Add(t.Period())
+	default:
+		return t.deadline
+	}
+}
+
+func (t *timerRepeatTicker) Active() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.active
+}
+
+func (t *timerRepeatTicker) FireCount() int {
+	return int(t.fireCount.Load())
+}
+
+func (t *timerRepeatTicker) Period() time.Duration {
+	return time.Duration(t.period.Load())
+}
+
+// Must be called under lock.
+func (t *timerRepeatTicker) cancel() {
+	t.generation++
+	t.active = false
+	t.running = false
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+// Must be called under lock.
+func (t *timerRepeatTicker) schedule(deadline time.Time) {
+	t.generation++
+	generation := t.generation
+
+	t.active = true
+	t.deadline = deadline
+	t.timer = t.clock.UntilFunc(deadline, func(now time.Time) {
+		t.tick(generation, now)
+	})
+}
+
+func (t *timerRepeatTicker) tick(generation uint64, now time.Time) {
+	t.lock.Lock()
+	if t.generation != generation {
+		t.lock.Unlock()
+		return
+	}
+
+	t.running = true
+	deadline := t.deadline
+	t.lock.Unlock()
+
+	t.fireCount.Add(1)
+
+	next, contin := t.action(now)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.generation != generation {
+		// Stopped or reset while running
+		return
+	}
+
+	t.running = false
+
+	if !contin {
+		t.cancel()
+		return
+	}
+
+	t.period.Store(int64(next))
+	t.schedule(deadline.Add(max(next, 0)))
 }
@@ -2,6 +2,7 @@ package chrono_test
 
 import (
 	"context"
+	"runtime"
 	"testing"
 	"time"
 
@@ -76,3 +77,263 @@ func TestSimTicker(t *testing.T) {
 	require.Equal(t, []int{1, 1}, res2)
 	require.Equal(t, []int{1, 1, 1, 1}, res3)
 }
+
+func TestSimTickerStopAndResetFromHandler(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+
+	var stopped []time.Time
+	var stoppingTicker chrono.Ticker
+	stoppingTicker = s.EveryFunc(time.Minute, func(now time.Time) bool {
+		stopped = append(stopped, now)
+		if len(stopped) == 2 {
+			stoppingTicker.Stop()
+		}
+		return true
+	})
+
+	var reset []time.Time
+	var resettingTicker chrono.Ticker
+	resettingTicker = s.EveryFunc(time.Minute, func(now time.Time) bool {
+		reset = append(reset, now)
+		if len(reset) == 1 {
+			resettingTicker.Reset(10 * time.Second)
+		}
+		return len(reset) < 3
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute)}, stopped)
+	require.Equal(t, []time.Time{start.Add(time.Minute), start.Add(70 * time.Second), start.Add(130 * time.Second)}, reset)
+}
+
+func TestRealTicker(t *testing.T) {
+	t.Parallel()
+
+	c := chrono.NewRealClock()
+	start := time.Now()
+
+	// Handlers of the same clock are not executed concurrently, so no synchronization is needed.
+	// Ticks are reported to the test goroutine after the ticker stops itself.
+	var ticks []time.Duration
+	var ticker chrono.Ticker
+	results := make(chan []time.Duration, 2)
+
+	c.AfterFunc(0, func(now time.Time) {
+		ticker = c.EveryFunc(50*time.Millisecond, func(now time.Time) bool {
+			ticks = append(ticks, now.Sub(start))
+
+			switch len(ticks) {
+			case 1:
+				ticker.Reset(20 * time.Millisecond)
+			case 3, 4:
+				ticker.Stop()
+				results <- append([]time.Duration(nil), ticks...)
+			}
+
+			return true
+		})
+	})
+
+	// Timers never fire early, so only lower bounds are checked:
+	// 50ms, then 20ms after reset, then the original period of 50ms
+	res := <-results
+	require.GreaterOrEqual(t, res[0], 50*time.Millisecond)
+	require.GreaterOrEqual(t, res[1]-res[0], 20*time.Millisecond)
+	require.GreaterOrEqual(t, res[2]-res[0], 70*time.Millisecond)
+	require.Equal(t, 50*time.Millisecond, ticker.Period())
+
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, 3, ticker.FireCount())
+	require.False(t, ticker.Active())
+
+	// Reset restarts stopped ticker
+	resetAt := time.Now()
+	ticker.Reset(10 * time.Millisecond)
+
+	res = <-results
+	require.Len(t, res, 4)
+	require.GreaterOrEqual(t, res[3], resetAt.Add(10*time.Millisecond).Sub(start))
+}
+
+func TestRealTickerNoGoroutineLeak(t *testing.T) {
+	// Not parallel, because goroutines are counted
+	baseline := runtime.NumGoroutine()
+
+	c := chrono.NewRealClock()
+
+	for i := 0; i < 100; i++ {
+		ticker := c.EveryFunc(time.Millisecond, func(now time.Time) bool {
+			return true
+		})
+
+		if i%2 == 0 {
+			ticker.Stop()
+		} else {
+			ticker.Reset(time.Millisecond)
+			time.AfterFunc(5*time.Millisecond, ticker.Stop)
+		}
+
+		c.EveryFunc(time.Millisecond, func(now time.Time) bool {
+			return false
+		})
+	}
+
+	// Not using require.Eventually, because it starts goroutines
+	for i := 0; i < 100 && runtime.NumGoroutine() > baseline; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.LessOrEqual(t, runtime.NumGoroutine(), baseline)
+}
+
+func TestTickerIntrospection(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+
+	var ticker chrono.Ticker
+	ticker = s.EveryFunc(time.Minute, func(now time.Time) bool {
+		require.True(t, ticker.Active())
+		require.Equal(t, now.Add(time.Minute), ticker.Deadline())
+		return ticker.FireCount() < 3
+	})
+
+	require.True(t, ticker.Active())
+	require.Equal(t, time.Minute, ticker.Period())
+	require.Equal(t, start.Add(time.Minute), ticker.Deadline())
+
+	ticker.ResetAt(start.Add(time.Hour))
+	require.Equal(t, start.Add(time.Hour), ticker.Deadline())
+
+	s.ProcessAll(context.Background())
+	require.False(t, ticker.Active())
+	require.True(t, ticker.Deadline().IsZero())
+	require.Equal(t, 3, ticker.FireCount())
+	require.Equal(t, start.Add(time.Hour+2*time.Minute), s.Now())
+
+	c := chrono.NewRealClock()
+	realTicker := c.EveryFunc(time.Hour, func(now time.Time) bool { return true })
+	require.True(t, realTicker.Active())
+	require.Equal(t, time.Hour, realTicker.Period())
+	require.WithinDuration(t, time.Now().Add(time.Hour), realTicker.Deadline(), time.Second)
+
+	realTicker.Stop()
+	require.False(t, realTicker.Active())
+	require.Equal(t, 0, realTicker.FireCount())
+
+	// Same as for the simulator, the deadline in the handler is the one of the next tick
+	type handlerState struct {
+		now      time.Time
+		active   bool
+		deadline time.Time
+	}
+
+	const period = 10 * time.Millisecond
+
+	ready := make(chan struct{})
+	states := make(chan handlerState, 1)
+	realTicker = c.EveryFunc(period, func(now time.Time) bool {
+		<-ready
+		states <- handlerState{
+			now:      now,
+			active:   realTicker.Active(),
+			deadline: realTicker.Deadline(),
+		}
+		return false
+	})
+	tickDeadline := realTicker.Deadline()
+	close(ready)
+
+	state := <-states
+	require.True(t, state.active)
+	require.True(t, state.deadline.After(state.now))
+	// Ticks missed by the moment of the handler are dropped
+	require.Greater(t, state.deadline.Sub(tickDeadline), time.Duration(0))
+	require.Zero(t, state.deadline.Sub(tickDeadline)%period)
+}
+
+func TestRepeatFunc(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+
+	var ticks []time.Duration
+	delay := time.Second
+
+	ticker := s.RepeatFunc(delay, func(now time.Time) (time.Duration, bool) {
+		ticks = append(ticks, now.Sub(start))
+		delay *= 2
+		return delay, len(ticks) < 4
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []time.Duration{time.Second, 3 * time.Second, 7 * time.Second, 15 * time.Second}, ticks)
+	require.Equal(t, 8*time.Second, ticker.Period())
+	require.Equal(t, 4, ticker.FireCount())
+	require.False(t, ticker.Active())
+
+	c := chrono.NewRealClock()
+	done := make(chan struct{})
+	realTicks := 0
+
+	c.RepeatFunc(10*time.Millisecond, func(now time.Time) (time.Duration, bool) {
+		realTicks++
+		if realTicks == 3 {
+			close(done)
+			return 0, false
+		}
+		return 0, true
+	})
+
+	<-done
+	require.Equal(t, 3, realTicks)
+}
+
+// Clock, which does not implement RepeatFunc natively.
+type plainClock struct {
+	chrono.Clock
+}
+
+func TestRepeatFuncFallback(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+	c := plainClock{s}
+
+	var ticks []time.Duration
+	delay := time.Second
+
+	var ticker chrono.Ticker
+	ticker = chrono.RepeatFunc(c, delay, func(now time.Time) (time.Duration, bool) {
+		ticks = append(ticks, now.Sub(start))
+		require.True(t, ticker.Active())
+		require.Equal(t, now.Add(delay), ticker.Deadline())
+
+		delay *= 2
+		return delay, len(ticks) < 4
+	})
+
+	s.ProcessAll(context.Background())
+
+	require.Equal(t, []time.Duration{time.Second, 3 * time.Second, 7 * time.Second, 15 * time.Second}, ticks)
+	require.Equal(t, 8*time.Second, ticker.Period())
+	require.Equal(t, 4, ticker.FireCount())
+	require.False(t, ticker.Active())
+
+	ticker.Reset(time.Second)
+	require.True(t, ticker.Active())
+	require.Equal(t, s.Now().Add(time.Second), ticker.Deadline())
+
+	ticker.Stop()
+	s.ProcessAll(context.Background())
+	require.Equal(t, 4, ticker.FireCount())
+	require.False(t, ticker.Active())
+}
@@ -1,58 +1,233 @@
 package chrono
 
-import "time"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type Timer interface {
+	// Reschedules the timer to fire after specified duration. Returns true if the timer was active.
 	Reset(d time.Duration) bool
+	// Same as Reset, but the timer fires at specified moment.
+	ResetAt(t time.Time) bool
+	// Prevents the timer from firing. Returns true if the timer was active.
 	Stop() bool
+	// Returns the moment when the timer is going to fire, or zero time if it is not active.
+	Deadline() time.Time
+	// Returns true if the timer is going to fire.
+	Active() bool
+	// Returns how many times the timer has fired (more than once only if it was reset after firing).
+	FireCount() int
 }
 
-// The timer which cannot be stopped, can be only reset.
-// Used to be returned by AfterFunc and UntilFunc when the deadline durection is zero.
-func newExpiredTimer(c Clock, f func(now time.Time)) *expiredTimer {
-	return &expiredTimer{
-		c: c,
-		f: f,
+// Timer of RealClock. Zero and negative durations are handled same way as positive ones -
+// the handler is executed asynchronously as soon as possible, and until then the timer can be stopped or reset.
+// Returns false if the clock is closed and the timer was not scheduled.
+// Absolute timers (UntilFunc) are rearmed on wall clock jumps, if the clock is configured to do so.
+func newRealTimer(c *RealClock, deadline time.Time, absolute bool, f func(now time.Time)) (_ *realTimer, scheduled bool) {
+	t := &realTimer{
+		c:        c,
+		f:        f,
+		absolute: absolute,
 	}
+
+	t.lock.Lock()
+	scheduled = t.schedule(deadline)
+	t.lock.Unlock()
+
+	return t, scheduled
 }
 
-type expiredTimer struct {
-	c Clock
+type realTimer struct {
+	c *RealClock
 	f func(now time.Time)
+
+	fireCount atomic.Int64
+
+	lock     sync.Mutex
+	timer    *time.Timer
+	pending  bool
+	deadline time.Time
+	absolute bool
+	// Incremented on each Stop and Reset to invalidate already scheduled firing.
+	generation uint64
+}
+
+var _ Timer = &realTimer{}
+
+func (t *realTimer) Stop() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.cancel()
 }
 
-func (t *expiredTimer) Reset(d time.Duration) bool {
-	t.c.AfterFunc(d, t.f)
-	return false
+func (t *realTimer) Reset(d time.Duration) bool {
+	return t.resetAt(time.Now().Add(d), false)
 }
 
-func (t *expiredTimer) Stop() bool {
-	return false
+func (t *realTimer) ResetAt(deadline time.Time) bool {
+	return t.resetAt(deadline, true)
 }
 
-func newSimTimer(sim *Simulator, deadline time.Time, action func(now time.Time)) (*simTimer, *Task) {
+func (t *realTimer) resetAt(deadline time.Time, absolute bool) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	wasPending := t.cancel()
+	t.absolute = absolute
+	t.schedule(deadline)
+
+	return wasPending
+}
+
+func (t *realTimer) Deadline() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.pending {
+		return time.Time{}
+	}
+
+	return t.deadline
+}
+
+func (t *realTimer) Active() bool {
+	return t.isPending()
+}
+
+func (t *realTimer) FireCount() int {
+	return int(t.fireCount.Load())
+}
+
+// Must be called under lock.
+func (t *realTimer) cancel() (wasPending bool) {
+	wasPending = t.pending
+
+	t.generation++
+	t.pending = false
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+
+	if wasPending {
+		t.c.unregister(t)
+	}
+
+	return wasPending
+}
+
+// Must be called under lock. Returns false if the clock is closed.
+func (t *realTimer) schedule(deadline time.Time) bool {
+	if !t.c.register(t) {
+		return false
+	}
+
+	generation := t.generation
+	t.pending = true
+	t.deadline = deadline
+	t.timer = time.AfterFunc(max(time.Until(deadline), 0), func() {
+		t.c.executeTaskIf(t.f, func() bool {
+			return t.fire(generation)
+		})
+	})
+
+	return true
+}
+
+// Marks timer as fired. Returns false if the firing is not actual anymore.
+func (t *realTimer) fire(generation uint64) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.generation != generation || !t.pending {
+		return false
+	}
+
+	t.pending = false
+	t.timer = nil
+	t.c.unregister(t)
+	t.fireCount.Add(1)
+
+	return true
+}
+
+func (t *realTimer) isPending() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.pending
+}
+
+func (t *realTimer) stopOnClose() {
+	t.Stop()
+}
+
+// Reschedules absolute timer against the wall time.
+func (t *realTimer) onWallClockJump() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.absolute || !t.pending {
+		return
+	}
+
+	t.cancel()
+	// Stripping monotonic clock reading, so that time left is calculated using wall clock
+	t.schedule(t.deadline.Round(0))
+}
+
+func newSimTimer(sim taskScheduler, deadline time.Time, action func(now time.Time)) (*simTimer, *Task) {
 	t := &simTimer{
 		sim: sim,
-		task: newTask(deadline, func(_ *Task, now time.Time) *Task {
-			action(now)
-			return nil
-		}),
 	}
 
+	t.task = newTask("timer", action, deadline, func(_ *Task, now time.Time) *Task {
+		t.fireCount.Add(1)
+		action(now)
+		return nil
+	})
+
 	return t, t.task
 }
 
+// Timer of the clocks, which are based on task queue - Simulator and EventLoopClock.
 type simTimer struct {
-	sim  *Simulator
-	task *Task
+	sim       taskScheduler
+	task      *Task
+	fireCount atomic.Int64
 }
 
 var _ Timer = &simTimer{}
 
 func (t *simTimer) Stop() bool {
-	return t.sim.removeTask(t.task)
+	return t.sim.removeTask(t.task, "Timer.Stop")
 }
 
 func (t *simTimer) Reset(d time.Duration) bool {
-	return t.sim.resetTask(t.task, d)
+	return t.sim.resetTask(t.task, d, "Timer.Reset")
+}
+
+func (t *simTimer) ResetAt(deadline time.Time) bool {
+	return t.sim.resetTaskAt(t.task, deadline, "Timer.ResetAt")
+}
+
+func (t *simTimer) Deadline() time.Time {
+	state := t.sim.taskState(t.task)
+	if !state.Pending {
+		return time.Time{}
+	}
+
+	return state.Deadline
+}
+
+func (t *simTimer) Active() bool {
+	return t.sim.taskState(t.task).Pending
+}
+
+func (t *simTimer) FireCount() int {
+	return int(t.fireCount.Load())
 }
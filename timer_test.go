@@ -2,6 +2,7 @@ package chrono_test
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -63,3 +64,84 @@ func TestSimTimer(t *testing.T) {
 	require.True(t, timer3fired)
 	require.True(t, timer4fired)
 }
+
+func TestRealTimer(t *testing.T) {
+	t.Parallel()
+
+	c := chrono.NewRealClock()
+
+	var fired atomic.Int32
+
+	// Handlers lock is occupied, so that timers with zero and negative delays can't fire until it is released
+	blocked := make(chan struct{})
+	release := make(chan struct{})
+	c.AfterFunc(0, func(now time.Time) {
+		close(blocked)
+		<-release
+	})
+	<-blocked
+
+	for _, d := range []time.Duration{0, -time.Second, time.Hour} {
+		timer := c.AfterFunc(d, func(now time.Time) {
+			fired.Add(1)
+		})
+		require.True(t, timer.Stop())
+		require.False(t, timer.Stop())
+	}
+
+	// Buffered, so that the handler never blocks and can fire any number of times
+	firings := make(chan struct{}, 10)
+	timer := c.AfterFunc(-time.Second, func(now time.Time) {
+		fired.Add(1)
+		firings <- struct{}{}
+	})
+	require.True(t, timer.Reset(0))
+
+	close(release)
+
+	<-firings
+	require.False(t, timer.Stop())
+
+	require.False(t, timer.Reset(time.Hour))
+	require.True(t, timer.Reset(time.Millisecond))
+
+	<-firings
+	time.Sleep(10 * time.Millisecond)
+	// Stopped timers never fire
+	require.Equal(t, int32(2), fired.Load())
+	require.Equal(t, 2, timer.FireCount())
+}
+
+func TestTimerIntrospection(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := chrono.NewSimulator(start)
+
+	timer := s.AfterFunc(time.Minute, func(now time.Time) {})
+	require.True(t, timer.Active())
+	require.Equal(t, start.Add(time.Minute), timer.Deadline())
+	require.Equal(t, 0, timer.FireCount())
+
+	require.True(t, timer.ResetAt(start.Add(time.Hour)))
+	require.Equal(t, start.Add(time.Hour), timer.Deadline())
+
+	s.ProcessAll(context.Background())
+	require.False(t, timer.Active())
+	require.True(t, timer.Deadline().IsZero())
+	require.Equal(t, 1, timer.FireCount())
+	require.Equal(t, start.Add(time.Hour), s.Now())
+
+	c := chrono.NewRealClock()
+	done := make(chan struct{})
+	deadline := time.Now().Add(time.Hour)
+
+	timer = c.UntilFunc(deadline, func(now time.Time) { close(done) })
+	require.True(t, timer.Active())
+	require.Equal(t, deadline, timer.Deadline())
+	require.True(t, timer.ResetAt(time.Now()))
+
+	<-done
+	require.False(t, timer.Active())
+	require.Equal(t, 1, timer.FireCount())
+}
@@ -0,0 +1,153 @@
+package chrono
+
+import (
+	"sync"
+	"time"
+)
+
+// WallClockJump describes detected discrepancy between wall clock and monotonic clock progress.
+// It happens when system time is changed (e.g. by NTP), or when the system was suspended.
+type WallClockJump struct {
+	DetectedAt time.Time
+	// How much wall clock moved in addition to the monotonic clock. Positive means wall clock moved forward.
+	Jump time.Duration
+}
+
+type WallClockWatchOpts struct {
+	// How often wall clock is compared with monotonic clock. Default is 1 second.
+	Interval time.Duration
+	// Minimal discrepancy to be reported. Default is 1 second.
+	Threshold time.Duration
+	// Reschedule timers with absolute deadlines (UntilFunc, ResetAt) to fire at their wall time.
+	// Without it, they fire after the duration calculated at the moment of scheduling.
+	RearmAbsoluteTimers bool
+}
+
+// WatchWallClock starts periodic detection of wall clock jumps.
+// onJump is called for each detected jump, it is executed same way as other handlers of the clock (can be nil).
+// Returned function stops the watching. Watching is also stopped when the clock is closed,
+// and is not started if the clock is already closed.
+func (c *RealClock) WatchWallClock(opts WallClockWatchOpts, onJump func(jump WallClockJump)) (stop func()) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = time.Second
+	}
+
+	w := &wallClockWatcher{
+		c:      c,
+		opts:   opts,
+		onJump: onJump,
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if !c.register(w) {
+		w.stopped = true
+		return w.stop
+	}
+
+	w.remember(time.Now())
+	w.timer = time.AfterFunc(opts.Interval, w.tick)
+
+	return w.stop
+}
+
+type wallClockWatcher struct {
+	c      *RealClock
+	opts   WallClockWatchOpts
+	onJump func(jump WallClockJump)
+
+	lock    sync.Mutex
+	timer   *time.Timer
+	stopped bool
+	// Previous reading, containing monotonic clock reading.
+	last time.Time
+	// Previous reading without monotonic clock reading, so that it is compared using wall clock.
+	lastWall time.Time
+}
+
+// Must be called under lock.
+func (w *wallClockWatcher) remember(now time.Time) {
+	w.last = now
+	w.lastWall = now.Round(0)
+}
+
+func (w *wallClockWatcher) tick() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.stopped {
+		return
+	}
+
+	if jump, detected := w.check(time.Now()); detected {
+		go w.report(jump)
+	}
+
+	w.timer.Reset(w.opts.Interval)
+}
+
+// Must be called under lock.
+func (w *wallClockWatcher) check(now time.Time) (WallClockJump, bool) {
+	monotonicElapsed := now.Sub(w.last)
+	wallElapsed := now.Round(0).Sub(w.lastWall)
+	w.remember(now)
+
+	jump := wallElapsed - monotonicElapsed
+	if jump < w.opts.Threshold && jump > -w.opts.Threshold {
+		return WallClockJump{}, false
+	}
+
+	return WallClockJump{DetectedAt: now, Jump: jump}, true
+}
+
+func (w *wallClockWatcher) report(jump WallClockJump) {
+	if w.opts.RearmAbsoluteTimers {
+		w.c.rearmOnWallClockJump()
+	}
+
+	if w.onJump != nil {
+		w.c.executeTaskIf(func(now time.Time) {
+			w.onJump(jump)
+		}, func() bool {
+			w.lock.Lock()
+			defer w.lock.Unlock()
+
+			return !w.stopped
+		})
+	}
+}
+
+func (w *wallClockWatcher) stop() {
+	w.lock.Lock()
+	w.stopped = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.lock.Unlock()
+
+	w.c.unregister(w)
+}
+
+func (w *wallClockWatcher) stopOnClose() {
+	w.stop()
+}
+
+// Watcher itself is not rearmed - it works with durations.
+func (w *wallClockWatcher) onWallClockJump() {}
+
+func (c *RealClock) rearmOnWallClockJump() {
+	c.registryLock.Lock()
+	tasks := make([]registeredTask, 0, len(c.registry))
+	for task := range c.registry {
+		tasks = append(tasks, task)
+	}
+	c.registryLock.Unlock()
+
+	for _, task := range tasks {
+		task.onWallClockJump()
+	}
+}
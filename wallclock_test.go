@@ -0,0 +1,96 @@
+package chrono
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Internal test, because system time can't be changed from a test. The jump is simulated by altering watcher state.
+func TestWallClockWatcher(t *testing.T) {
+	t.Parallel()
+
+	c := NewRealClock()
+
+	jumps := make(chan WallClockJump, 10)
+	stop := c.WatchWallClock(WallClockWatchOpts{Interval: 5 * time.Millisecond}, func(jump WallClockJump) {
+		jumps <- jump
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	require.Empty(t, jumps)
+
+	w := &wallClockWatcher{
+		c:    c,
+		opts: WallClockWatchOpts{Threshold: time.Second, RearmAbsoluteTimers: true},
+		onJump: func(jump WallClockJump) {
+			jumps <- jump
+		},
+	}
+
+	w.remember(time.Now())
+	_, detected := w.check(time.Now().Add(time.Minute))
+	require.False(t, detected)
+
+	// Wall clock moved one hour forward comparing to monotonic clock
+	w.lastWall = w.lastWall.Add(-time.Hour)
+	jump, detected := w.check(time.Now())
+	require.True(t, detected)
+	require.InDelta(t, time.Hour, jump.Jump, float64(time.Second))
+
+	deadline := time.Now().Add(time.Hour)
+	absoluteTimer := c.UntilFunc(deadline, func(now time.Time) {})
+	relativeTimer := c.AfterFunc(time.Hour, func(now time.Time) {})
+
+	w.report(jump)
+	require.Equal(t, jump, <-jumps)
+
+	require.True(t, absoluteTimer.Active())
+	require.True(t, absoluteTimer.Deadline().Equal(deadline))
+	// Monotonic clock reading is stripped, so that the timer follows wall clock from now on
+	require.Equal(t, deadline.Round(0), absoluteTimer.(*realTimer).deadline)
+	require.True(t, relativeTimer.Active())
+
+	absoluteTimer.Stop()
+	relativeTimer.Stop()
+}
+
+func TestWallClockWatcherStoppedOnClose(t *testing.T) {
+	t.Parallel()
+
+	c := NewRealClock()
+
+	jumps := make(chan WallClockJump, 10)
+	c.WatchWallClock(WallClockWatchOpts{Interval: time.Millisecond}, func(jump WallClockJump) {
+		jumps <- jump
+	})
+
+	c.registryLock.Lock()
+	require.Len(t, c.registry, 1)
+	var w *wallClockWatcher
+	for task := range c.registry {
+		w = task.(*wallClockWatcher)
+	}
+	c.registryLock.Unlock()
+
+	require.NoError(t, c.Close(context.Background()))
+
+	w.lock.Lock()
+	require.True(t, w.stopped)
+	w.lock.Unlock()
+
+	// Jump, detected after closing, is not reported
+	w.report(WallClockJump{Jump: time.Hour})
+	require.Empty(t, jumps)
+
+	// Watching is not started on closed clock
+	stop := c.WatchWallClock(WallClockWatchOpts{}, nil)
+	stop()
+
+	c.registryLock.Lock()
+	require.Empty(t, c.registry)
+	c.registryLock.Unlock()
+}